@@ -0,0 +1,172 @@
+// Package arc reads and writes Titan Quest resource archives (.arc).
+//
+// The data of every file is split into parts that are zlib compressed on their own.
+// The part table, the file names and the file table follow the data
+// at the end of the archive.
+package arc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const (
+	version = 3
+	// dataStart is where the game's own archives start storing file data.
+	dataStart = 2048
+	// partSize is the maximum uncompressed size of a single part.
+	partSize      = 256 * 1024
+	storeDeflated = 3
+)
+
+var magic = [4]byte{'A', 'R', 'C', 0}
+
+type header struct {
+	Magic           [4]byte
+	Version         uint32
+	FileCount       uint32
+	PartCount       uint32
+	PartTableSize   uint32
+	NameTableSize   uint32
+	PartTableOffset uint32
+}
+
+type part struct {
+	Offset         uint32
+	CompressedSize uint32
+	Size           uint32
+}
+
+type fileEntry struct {
+	Storage        uint32
+	Offset         uint32
+	CompressedSize uint32
+	Size           uint32
+	Checksum       uint32
+	Modified       uint64
+	PartCount      uint32
+	FirstPart      uint32
+	NameLength     uint32
+	NameOffset     uint32
+}
+
+// Archive is an opened resource archive.
+type Archive struct {
+	data  []byte
+	parts []part
+	files map[string]fileEntry
+	names []string
+}
+
+// CleanName converts a file name into the form used inside of archives.
+func CleanName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "\\", "/"))
+}
+
+// Open reads the archive stored at path.
+func Open(path string) (*Archive, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %v", err)
+	}
+	a, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse archive %s: %v", path, err)
+	}
+	return a, nil
+}
+
+// Parse reads an archive from r.
+func Parse(r io.Reader) (*Archive, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %v", err)
+	}
+	var h header
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	if h.Magic != magic || h.Version != version {
+		return nil, fmt.Errorf("unsupported archive version %d", h.Version)
+	}
+	a := Archive{
+		data:  data,
+		parts: make([]part, h.PartCount),
+		files: make(map[string]fileEntry, h.FileCount),
+	}
+	tables := uint64(h.PartTableOffset)
+	if tables+uint64(h.PartTableSize)+uint64(h.NameTableSize) > uint64(len(data)) {
+		return nil, fmt.Errorf("tables are out of bounds")
+	}
+	tr := bytes.NewReader(data[tables:])
+	if err := binary.Read(tr, binary.LittleEndian, a.parts); err != nil {
+		return nil, fmt.Errorf("failed to read part table: %v", err)
+	}
+	namesStart := tables + uint64(h.PartTableSize)
+	names := data[namesStart : namesStart+uint64(h.NameTableSize)]
+	entries := make([]fileEntry, h.FileCount)
+	if _, err := tr.Seek(int64(h.PartTableSize+h.NameTableSize), io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file table: %v", err)
+	}
+	if err := binary.Read(tr, binary.LittleEndian, entries); err != nil {
+		return nil, fmt.Errorf("failed to read file table: %v", err)
+	}
+	for i, e := range entries {
+		end := uint64(e.NameOffset) + uint64(e.NameLength)
+		if end > uint64(len(names)) {
+			return nil, fmt.Errorf("name of file %d is out of bounds", i)
+		}
+		if uint64(e.FirstPart)+uint64(e.PartCount) > uint64(len(a.parts)) {
+			return nil, fmt.Errorf("parts of file %d are out of bounds", i)
+		}
+		name := CleanName(string(names[e.NameOffset:end]))
+		a.files[name] = e
+		a.names = append(a.names, name)
+	}
+	sort.Strings(a.names)
+	return &a, nil
+}
+
+// Files returns the names of all files sorted by name.
+func (a *Archive) Files() []string {
+	return append([]string(nil), a.names...)
+}
+
+// Read returns the content of the file called name.
+func (a *Archive) Read(name string) ([]byte, error) {
+	e, ok := a.files[CleanName(name)]
+	if !ok {
+		return nil, fmt.Errorf("file %s does not exist", name)
+	}
+	var b bytes.Buffer
+	for _, p := range a.parts[e.FirstPart : e.FirstPart+e.PartCount] {
+		end := uint64(p.Offset) + uint64(p.CompressedSize)
+		if end > uint64(len(a.data)) {
+			return nil, fmt.Errorf("part of %s is out of bounds", name)
+		}
+		raw := a.data[p.Offset:end]
+		if p.CompressedSize == p.Size {
+			b.Write(raw)
+			continue
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %v", name, err)
+		}
+		_, err = io.Copy(&b, zr)
+		zr.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress %s: %v", name, err)
+		}
+	}
+	if uint32(b.Len()) != e.Size {
+		return nil, fmt.Errorf("file %s has %d bytes but should have %d", name, b.Len(), e.Size)
+	}
+	return b.Bytes(), nil
+}
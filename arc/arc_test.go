@@ -0,0 +1,112 @@
+package arc
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	large := make([]byte, 3*partSize+17)
+	rand.New(rand.NewSource(1)).Read(large)
+	testData := []struct {
+		Name string
+		In   map[string][]byte
+		Out  []string
+	}{
+		{
+			Name: "SingleFile",
+			In: map[string][]byte{
+				`Text\ModStrings.txt`: []byte("tagTest=Test\r\n"),
+			},
+			Out: []string{"text/modstrings.txt"},
+		},
+		{
+			Name: "MultipleParts",
+			In: map[string][]byte{
+				"random.bin":  large,
+				"zeroes.bin":  make([]byte, partSize+1),
+				"empty.txt":   nil,
+				"strings.txt": []byte("tagA=A\r\ntagB=B\r\n"),
+			},
+			Out: []string{"empty.txt", "random.bin", "strings.txt", "zeroes.bin"},
+		},
+		{
+			Name: "Empty",
+			In:   nil,
+			Out:  nil,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			w := NewWriter()
+			for name, data := range td.In {
+				if err := w.Add(name, data); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			var b bytes.Buffer
+			if _, err := w.WriteTo(&b); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			a, err := Parse(&b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(a.Files(), td.Out); diff != nil {
+				t.Errorf("result differs from expected files: %+v", diff)
+			}
+			for name, data := range td.In {
+				got, err := a.Read(name)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !bytes.Equal(got, data) {
+					t.Errorf("content of %s differs", name)
+				}
+			}
+		})
+	}
+}
+
+func TestWriterDuplicate(t *testing.T) {
+	w := NewWriter()
+	if err := w.Add("text/modstrings.txt", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Add(`Text\ModStrings.txt`, nil); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		OK   bool
+	}{
+		{
+			Name: "FileNotExists",
+			In:   "../testData/IDoNotExist.arc",
+			OK:   false,
+		},
+		{
+			Name: "InvalidArchive",
+			In:   "../testData/validEquipment.yml",
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			_, err := Open(td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package arc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+)
+
+type file struct {
+	name string
+	data []byte
+}
+
+// Writer packs files into an archive.
+type Writer struct {
+	files []file
+	names map[string]bool
+}
+
+// NewWriter creates an empty archive writer.
+func NewWriter() *Writer {
+	return &Writer{names: make(map[string]bool)}
+}
+
+// Add stores data as the file called name.
+func (w *Writer) Add(name string, data []byte) error {
+	name = CleanName(name)
+	if w.names[name] {
+		return fmt.Errorf("file %s was added twice", name)
+	}
+	w.names[name] = true
+	w.files = append(w.files, file{name: name, data: data})
+	return nil
+}
+
+// WriteTo writes the archive to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var data bytes.Buffer
+	data.Write(make([]byte, dataStart))
+	var parts []part
+	var names bytes.Buffer
+	entries := make([]fileEntry, 0, len(w.files))
+	for _, f := range w.files {
+		e := fileEntry{
+			Storage:    storeDeflated,
+			Offset:     uint32(data.Len()),
+			Size:       uint32(len(f.data)),
+			Checksum:   adler32.Checksum(f.data),
+			FirstPart:  uint32(len(parts)),
+			NameLength: uint32(len(f.name)),
+			NameOffset: uint32(names.Len()),
+		}
+		for start := 0; start < len(f.data); start += partSize {
+			end := start + partSize
+			if end > len(f.data) {
+				end = len(f.data)
+			}
+			chunk, err := compress(f.data[start:end])
+			if err != nil {
+				return 0, fmt.Errorf("failed to compress %s: %v", f.name, err)
+			}
+			parts = append(parts, part{
+				Offset:         uint32(data.Len()),
+				CompressedSize: uint32(len(chunk)),
+				Size:           uint32(end - start),
+			})
+			data.Write(chunk)
+			e.CompressedSize += uint32(len(chunk))
+			e.PartCount++
+		}
+		names.WriteString(f.name)
+		names.WriteByte(0)
+		entries = append(entries, e)
+	}
+	h := header{
+		Magic:           magic,
+		Version:         version,
+		FileCount:       uint32(len(entries)),
+		PartCount:       uint32(len(parts)),
+		PartTableSize:   uint32(len(parts) * binary.Size(part{})),
+		NameTableSize:   uint32(names.Len()),
+		PartTableOffset: uint32(data.Len()),
+	}
+	b := data.Bytes()
+	var hb bytes.Buffer
+	binary.Write(&hb, binary.LittleEndian, h)
+	copy(b, hb.Bytes())
+	binary.Write(&data, binary.LittleEndian, parts)
+	data.Write(names.Bytes())
+	binary.Write(&data, binary.LittleEndian, entries)
+	return data.WriteTo(out)
+}
+
+// compress deflates a part.
+// Parts that do not get smaller are stored as they are,
+// readers detect them by their compressed size matching their size.
+func compress(b []byte) ([]byte, error) {
+	var c bytes.Buffer
+	zw := zlib.NewWriter(&c)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if c.Len() >= len(b) {
+		return b, nil
+	}
+	return c.Bytes(), nil
+}
+
+// WriteFile writes the archive to the file at path.
+func (w *Writer) WriteFile(path string) error {
+	var b bytes.Buffer
+	if _, err := w.WriteTo(&b); err != nil {
+		return fmt.Errorf("failed to build archive: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write archive %s: %v", path, err)
+	}
+	return nil
+}
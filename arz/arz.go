@@ -0,0 +1,287 @@
+// Package arz reads and writes compiled Titan Quest databases (database.arz).
+//
+// An archive starts with a fixed header that points at a record index and a
+// string table. Every string in the archive (record paths, keys and string
+// values) is stored once in the string table and referenced by its index.
+// The fields of a record are zlib compressed and decoded on demand.
+package arz
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+const (
+	headerSize = 24
+	magic      = 2
+	version    = 3
+)
+
+// Data types of record fields.
+const (
+	typeInt    = 0
+	typeFloat  = 1
+	typeString = 2
+	typeBool   = 3
+)
+
+type header struct {
+	Magic            uint16
+	Version          uint16
+	RecordTableStart uint32
+	RecordTableSize  uint32
+	RecordCount      uint32
+	StringTableStart uint32
+	StringTableSize  uint32
+}
+
+type entry struct {
+	class  string
+	offset uint32
+	size   uint32
+}
+
+// Archive is an opened database.
+type Archive struct {
+	data    []byte
+	strings []string
+	records map[string]entry
+	names   []string
+}
+
+// CleanPath converts a record path into the form used inside of archives.
+// Record paths are case insensitive and use backslashes as separators.
+func CleanPath(path string) string {
+	return strings.ToLower(strings.ReplaceAll(path, "/", "\\"))
+}
+
+// Open reads the archive stored at path.
+func Open(path string) (*Archive, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+	a, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database %s: %v", path, err)
+	}
+	return a, nil
+}
+
+// Parse reads an archive from r.
+// Only the index is decoded, records are decompressed when they are requested.
+func Parse(r io.Reader) (*Archive, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %v", err)
+	}
+	var h header
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	if h.Magic != magic || h.Version != version {
+		return nil, fmt.Errorf("unsupported archive version %d.%d", h.Magic, h.Version)
+	}
+	a := Archive{
+		data:    data,
+		records: make(map[string]entry, h.RecordCount),
+	}
+	strs, err := section(data, h.StringTableStart, h.StringTableSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read string table: %v", err)
+	}
+	if err := a.readStrings(strs); err != nil {
+		return nil, fmt.Errorf("failed to read string table: %v", err)
+	}
+	index, err := section(data, h.RecordTableStart, h.RecordTableSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record table: %v", err)
+	}
+	if err := a.readIndex(index, h.RecordCount); err != nil {
+		return nil, fmt.Errorf("failed to read record table: %v", err)
+	}
+	return &a, nil
+}
+
+func section(data []byte, start, size uint32) ([]byte, error) {
+	end := uint64(start) + uint64(size)
+	if end > uint64(len(data)) {
+		return nil, fmt.Errorf("section %d-%d is out of bounds", start, end)
+	}
+	return data[start:end], nil
+}
+
+func (a *Archive) readStrings(b []byte) error {
+	r := bytes.NewReader(b)
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return err
+	}
+	a.strings = make([]string, 0, count)
+	for i := uint32(0); i < count; i++ {
+		s, err := readString(r)
+		if err != nil {
+			return fmt.Errorf("string %d: %v", i, err)
+		}
+		a.strings = append(a.strings, s)
+	}
+	return nil
+}
+
+func (a *Archive) readIndex(b []byte, count uint32) error {
+	r := bytes.NewReader(b)
+	for i := uint32(0); i < count; i++ {
+		var id uint32
+		if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+			return fmt.Errorf("record %d: %v", i, err)
+		}
+		name, err := a.str(id)
+		if err != nil {
+			return fmt.Errorf("record %d: %v", i, err)
+		}
+		class, err := readString(r)
+		if err != nil {
+			return fmt.Errorf("record %s: %v", name, err)
+		}
+		var loc struct {
+			Offset   uint32
+			Size     uint32
+			Modified uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &loc); err != nil {
+			return fmt.Errorf("record %s: %v", name, err)
+		}
+		name = CleanPath(name)
+		a.records[name] = entry{class: class, offset: loc.Offset + headerSize, size: loc.Size}
+		a.names = append(a.names, name)
+	}
+	sort.Strings(a.names)
+	return nil
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	var l uint32
+	if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+		return "", err
+	}
+	if int64(l) > int64(r.Len()) {
+		return "", fmt.Errorf("string length %d is out of bounds", l)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (a *Archive) str(id uint32) (string, error) {
+	if int(id) >= len(a.strings) {
+		return "", fmt.Errorf("string %d is not in the string table", id)
+	}
+	return a.strings[id], nil
+}
+
+// Has reports whether the archive contains the record at path.
+func (a *Archive) Has(path string) bool {
+	_, ok := a.records[CleanPath(path)]
+	return ok
+}
+
+// Class returns the class of the record at path without decoding the record.
+func (a *Archive) Class(path string) (string, bool) {
+	e, ok := a.records[CleanPath(path)]
+	return e.class, ok
+}
+
+// Records returns the paths of all records sorted by name.
+func (a *Archive) Records() []string {
+	return append([]string(nil), a.names...)
+}
+
+// List returns the paths of all records below dir sorted by name.
+func (a *Archive) List(dir string) []string {
+	prefix := strings.TrimSuffix(CleanPath(dir), "\\") + "\\"
+	i := sort.SearchStrings(a.names, prefix)
+	var names []string
+	for ; i < len(a.names) && strings.HasPrefix(a.names[i], prefix); i++ {
+		names = append(names, a.names[i])
+	}
+	return names
+}
+
+// Record decodes the fields of the record at path.
+// Numbers are formatted the way they appear in dbr files.
+func (a *Archive) Record(path string) (*dbr.Record, error) {
+	e, ok := a.records[CleanPath(path)]
+	if !ok {
+		return nil, fmt.Errorf("record %s does not exist", path)
+	}
+	compressed, err := section(a.data, e.offset, e.size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record %s: %v", path, err)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress record %s: %v", path, err)
+	}
+	defer zr.Close()
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress record %s: %v", path, err)
+	}
+	rec, err := a.decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode record %s: %v", path, err)
+	}
+	return rec, nil
+}
+
+func (a *Archive) decode(raw []byte) (*dbr.Record, error) {
+	rec := dbr.New()
+	r := bytes.NewReader(raw)
+	for r.Len() > 0 {
+		var f struct {
+			Type  uint16
+			Count uint16
+			Key   uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &f); err != nil {
+			return nil, err
+		}
+		key, err := a.str(f.Key)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, f.Count)
+		for i := range values {
+			var v uint32
+			if err := binary.Read(r, binary.LittleEndian, &v); err != nil {
+				return nil, fmt.Errorf("field %s: %v", key, err)
+			}
+			switch f.Type {
+			case typeInt, typeBool:
+				values[i] = strconv.Itoa(int(int32(v)))
+			case typeFloat:
+				values[i] = strconv.FormatFloat(float64(math.Float32frombits(v)), 'f', 6, 32)
+			case typeString:
+				if values[i], err = a.str(v); err != nil {
+					return nil, fmt.Errorf("field %s: %v", key, err)
+				}
+			default:
+				return nil, fmt.Errorf("field %s has unknown type %d", key, f.Type)
+			}
+		}
+		rec.SetValues(key, values...)
+	}
+	return rec, nil
+}
@@ -0,0 +1,212 @@
+package arz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+// testArchive builds an archive holding one empty record for every path.
+func testArchive(t *testing.T, paths ...string) *Archive {
+	w := NewWriter()
+	for _, path := range paths {
+		if err := w.Add(path, dbr.New()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var b bytes.Buffer
+	if _, err := w.WriteTo(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, err := Parse(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return a
+}
+
+func TestOpen(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  []string
+		OK   bool
+	}{
+		{
+			Name: "ValidDatabase",
+			In:   "../testData/database.arz",
+			Out: []string{
+				`records\creature\monster\test\testmonster.dbr`,
+				`records\creature\monster\test\testmonsternoweight.dbr`,
+				`records\creature\npc\merchant\testmerchant.dbr`,
+				`records\item\artifacts\arcaneformulae\testformula.dbr`,
+				`records\item\artifacts\testartifact.dbr`,
+				`records\item\containers\testchest.dbr`,
+				`records\item\equipmentamulet\testamulet.dbr`,
+				`records\item\equipmentamulet\testsetamulet.dbr`,
+				`records\item\equipmentring\testsetring.dbr`,
+				`records\item\equipmentshield\testshield.dbr`,
+				`records\item\equipmentweapon\staff\teststaff.dbr`,
+				`records\item\equipmentweapon\sword\testcostsword.dbr`,
+				`records\item\equipmentweapon\sword\testsword.dbr`,
+				`records\item\lootmagicalaffixes\completionbonus\testrelicbonus.dbr`,
+				`records\item\lootmagicalaffixes\prefix\default\testlevelprefix.dbr`,
+				`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+				`records\item\lootmagicalaffixes\prefix\default\testweaponprefix.dbr`,
+				`records\item\lootmagicalaffixes\prefix\tablesweapons\testtable.dbr`,
+				`records\item\lootmagicalaffixes\suffix\default\testeasesuffix.dbr`,
+				`records\item\lootmagicalaffixes\suffix\default\testsuffix.dbr`,
+				`records\item\lootmagicalaffixes\suffix\tablesjewelry\testtable.dbr`,
+				`records\item\relics\testrelic.dbr`,
+				`records\item\sets\testset.dbr`,
+			},
+			OK: true,
+		},
+		{
+			Name: "FileNotExists",
+			In:   "../testData/IDoNotExist.arz",
+			OK:   false,
+		},
+		{
+			Name: "InvalidDatabase",
+			In:   "../testData/validEquipment.yml",
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			a, err := Open(td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				return
+			}
+			if diff := deep.Equal(a.Records(), td.Out); diff != nil {
+				t.Errorf("result differs from expected records: %+v", diff)
+			}
+			for _, record := range td.Out {
+				if !a.Has(strings.ReplaceAll(record, `\`, "/")) {
+					t.Errorf("expected record %s to exist by its unix path", record)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	a, err := Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := Parse(bytes.NewReader(a.data[:headerSize+8])); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestList(t *testing.T) {
+	a := testArchive(t,
+		"records/item/equipmentamulet/testamulet.dbr",
+		"records/item/lootmagicalaffixes/suffix/default/testsuffix.dbr",
+		"records/item/lootmagicalaffixes/prefix/default/testprefix.dbr",
+	)
+	testData := []struct {
+		Name string
+		In   string
+		Out  []string
+	}{
+		{
+			Name: "UnixPath",
+			In:   "records/item/lootmagicalaffixes",
+			Out: []string{
+				`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+				`records\item\lootmagicalaffixes\suffix\default\testsuffix.dbr`,
+			},
+		},
+		{
+			Name: "WindowsPathTrailingSeparator",
+			In:   `Records\Item\EquipmentAmulet\`,
+			Out: []string{
+				`records\item\equipmentamulet\testamulet.dbr`,
+			},
+		},
+		{
+			Name: "PartialDirectoryName",
+			In:   "records/item/equipment",
+			Out:  nil,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			if diff := deep.Equal(a.List(td.In), td.Out); diff != nil {
+				t.Errorf("result differs from expected records: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	a, err := Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name      string
+		In        string
+		OutClass  string
+		OutRecord string
+		OK        bool
+	}{
+		{
+			Name:     "Item",
+			In:       "records/item/equipmentamulet/testamulet.dbr",
+			OutClass: "ArmorJewelry_Amulet",
+			OutRecord: "templateName,database\\Templates\\JewelryAmulet.tpl,\nClass,ArmorJewelry_Amulet,\n" +
+				"FileDescription,Test amulet,\nitemNameTag,tagTestAmulet,\nitemLevel,20,\n",
+			OK: true,
+		},
+		{
+			Name:     "FloatField",
+			In:       `RECORDS\ITEM\LOOTMAGICALAFFIXES\PREFIX\DEFAULT\TESTPREFIX.DBR`,
+			OutClass: "LootRandomizer",
+			OutRecord: "templateName,database\\Templates\\LootRandomizer.tpl,\nClass,LootRandomizer,\n" +
+				"lootRandomizerName,tagTestPrefix,\ncharacterStrength,10,\noffensivePhysicalModifier,5.500000,\n",
+			OK: true,
+		},
+		{
+			Name: "MissingRecord",
+			In:   "records/item/idonotexist.dbr",
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			class, ok := a.Class(td.In)
+			if ok != td.OK || ok != a.Has(td.In) {
+				t.Errorf("expected record to exist to be %t", td.OK)
+			}
+			if class != td.OutClass {
+				t.Errorf("expected class %s got %s instead", td.OutClass, class)
+			}
+			r, err := a.Record(td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				return
+			}
+			if diff := deep.Equal(string(r.Bytes()), td.OutRecord); diff != nil {
+				t.Errorf("result differs from expected record: %+v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package arz
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+type compiled struct {
+	name  uint32
+	class string
+	data  []byte
+}
+
+// Writer compiles dbr records into an archive.
+// Fields are typed by their values since the templates are not available:
+// whole numbers become integers, other numbers floats and everything else strings.
+// Empty fields are dropped the same way ArtManager drops them.
+// References to other records are stored as cleaned paths so archives built on
+// unix systems resolve the same way as the ones built by ArtManager.
+type Writer struct {
+	strings []string
+	ids     map[string]uint32
+	records []compiled
+	paths   map[string]bool
+}
+
+// NewWriter creates an empty archive writer.
+func NewWriter() *Writer {
+	return &Writer{
+		ids:   make(map[string]uint32),
+		paths: make(map[string]bool),
+	}
+}
+
+func (w *Writer) id(s string) uint32 {
+	if id, ok := w.ids[s]; ok {
+		return id
+	}
+	id := uint32(len(w.strings))
+	w.strings = append(w.strings, s)
+	w.ids[s] = id
+	return id
+}
+
+// Add compiles rec and stores it at path.
+func (w *Writer) Add(path string, rec *dbr.Record) error {
+	path = CleanPath(path)
+	if w.paths[path] {
+		return fmt.Errorf("record %s was added twice", path)
+	}
+	var raw bytes.Buffer
+	var class string
+	for _, f := range rec.Fields() {
+		values := f.Values()
+		if len(values) == 0 {
+			continue
+		}
+		if f.Key == "Class" {
+			class = f.Value
+		}
+		if len(values) > math.MaxUint16 {
+			return fmt.Errorf("field %s of %s has too many values", f.Key, path)
+		}
+		typ := fieldType(values)
+		binary.Write(&raw, binary.LittleEndian, []uint16{typ, uint16(len(values))})
+		binary.Write(&raw, binary.LittleEndian, w.id(f.Key))
+		for _, v := range values {
+			binary.Write(&raw, binary.LittleEndian, w.encode(typ, v))
+		}
+	}
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return fmt.Errorf("failed to compress %s: %v", path, err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress %s: %v", path, err)
+	}
+	w.paths[path] = true
+	w.records = append(w.records, compiled{name: w.id(path), class: class, data: data.Bytes()})
+	return nil
+}
+
+// AddDir adds every .dbr file below root.
+// The record paths are the file paths relative to root.
+func (w *Writer) AddDir(root string) error {
+	return w.addDir(root, false)
+}
+
+// MergeDir adds every .dbr file below root like AddDir
+// but skips the records that were added before, so they shadow the ones below root.
+func (w *Writer) MergeDir(root string) error {
+	return w.addDir(root, true)
+}
+
+func (w *Writer) addDir(root string, skipAdded bool) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.EqualFold(filepath.Ext(path), ".dbr") {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if skipAdded && w.paths[CleanPath(rel)] {
+			return nil
+		}
+		rec, err := dbr.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		return w.Add(rel, rec)
+	})
+}
+
+func fieldType(values []string) uint16 {
+	typ := uint16(typeInt)
+	for _, v := range values {
+		if _, err := strconv.ParseInt(v, 10, 32); err == nil {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 32); err == nil {
+			typ = typeFloat
+			continue
+		}
+		return typeString
+	}
+	return typ
+}
+
+func (w *Writer) encode(typ uint16, v string) uint32 {
+	switch typ {
+	case typeInt:
+		i, _ := strconv.ParseInt(v, 10, 32)
+		return uint32(int32(i))
+	case typeFloat:
+		f, _ := strconv.ParseFloat(v, 32)
+		return math.Float32bits(float32(f))
+	}
+	if strings.HasSuffix(strings.ToLower(v), ".dbr") {
+		v = CleanPath(v)
+	}
+	return w.id(v)
+}
+
+// WriteTo writes the archive to out.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var data, index, strs bytes.Buffer
+	for _, r := range w.records {
+		binary.Write(&index, binary.LittleEndian, r.name)
+		binary.Write(&index, binary.LittleEndian, uint32(len(r.class)))
+		index.WriteString(r.class)
+		binary.Write(&index, binary.LittleEndian, []uint32{uint32(data.Len()), uint32(len(r.data))})
+		// The modification time is left empty to keep builds reproducible.
+		binary.Write(&index, binary.LittleEndian, uint64(0))
+		data.Write(r.data)
+	}
+	binary.Write(&strs, binary.LittleEndian, uint32(len(w.strings)))
+	for _, s := range w.strings {
+		binary.Write(&strs, binary.LittleEndian, uint32(len(s)))
+		strs.WriteString(s)
+	}
+	h := header{
+		Magic:            magic,
+		Version:          version,
+		RecordTableStart: uint32(headerSize + data.Len()),
+		RecordTableSize:  uint32(index.Len()),
+		RecordCount:      uint32(len(w.records)),
+		StringTableStart: uint32(headerSize + data.Len() + index.Len()),
+		StringTableSize:  uint32(strs.Len()),
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, h)
+	b.Write(data.Bytes())
+	b.Write(index.Bytes())
+	b.Write(strs.Bytes())
+	footer := []uint32{
+		adler32.Checksum(b.Bytes()),
+		adler32.Checksum(strs.Bytes()),
+		adler32.Checksum(data.Bytes()),
+		adler32.Checksum(index.Bytes()),
+	}
+	binary.Write(&b, binary.LittleEndian, footer)
+	return b.WriteTo(out)
+}
+
+// WriteFile writes the archive to the file at path.
+func (w *Writer) WriteFile(path string) error {
+	var b bytes.Buffer
+	if _, err := w.WriteTo(&b); err != nil {
+		return fmt.Errorf("failed to build database: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write database %s: %v", path, err)
+	}
+	return nil
+}
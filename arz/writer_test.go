@@ -0,0 +1,123 @@
+package arz
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestWriter(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "Types",
+			In:   "Class,LootItemTable_FixedWeight,\nlootWeight1,-100,\nprefixRandomizerChance,12.5,\nActorName,,\n",
+			Out:  "Class,LootItemTable_FixedWeight,\nlootWeight1,-100,\nprefixRandomizerChance,12.500000,\n",
+		},
+		{
+			Name: "MultiValue",
+			In:   "itemNames,records/item/a.dbr;records/item/b.dbr,\nbellSlope,1;2.5,\n",
+			Out:  "itemNames,records\\item\\a.dbr;records\\item\\b.dbr,\nbellSlope,1.000000;2.500000,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := dbr.Parse(strings.NewReader(td.In))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			w := NewWriter()
+			if err := w.Add("records/test/Table.dbr", r); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var b bytes.Buffer
+			if _, err := w.WriteTo(&b); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			a, err := Parse(&b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			out, err := a.Record(`records\test\table.dbr`)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(string(out.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected record: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestWriterDuplicate(t *testing.T) {
+	w := NewWriter()
+	if err := w.Add("records/test/table.dbr", dbr.New()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Add(`Records\Test\Table.dbr`, dbr.New()); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestWriterAddDir(t *testing.T) {
+	w := NewWriter()
+	if err := w.AddDir("../testData/database"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "database.arz")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want, err := ioutil.ReadFile("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("compiled test database differs from ../testData/database.arz")
+	}
+}
+
+func TestWriterMergeDir(t *testing.T) {
+	w := NewWriter()
+	shadow := dbr.New()
+	shadow.Set("Class", "Shadow")
+	if err := w.Add(`records\creature\npc\merchant\testmerchant.dbr`, shadow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.AddDir("../testData/database"); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	w = NewWriter()
+	if err := w.Add(`records\creature\npc\merchant\testmerchant.dbr`, shadow); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.MergeDir("../testData/database"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "database.arz")
+	if err := w.WriteFile(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, err := Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if class, _ := a.Class(`records\creature\npc\merchant\testmerchant.dbr`); class != "Shadow" {
+		t.Errorf("merchant has class %s, expected the shadowing record", class)
+	}
+	if !a.Has(`records\item\relics\testrelic.dbr`) {
+		t.Error("record records\\item\\relics\\testrelic.dbr is missing")
+	}
+}
@@ -0,0 +1,191 @@
+// Package dbr reads and writes Titan Quest database records.
+//
+// A record is a plain text file with one "key,value," entry per line.
+// Fields holding several values separate them with a ";".
+// The order of the fields as well as the line endings are kept so a parsed
+// record serialises back into the exact bytes it was read from.
+package dbr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const valueSeparator = ";"
+
+// Field is a single key value pair of a record.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Values splits the raw value of a field into its single values.
+func (f Field) Values() []string {
+	if f.Value == "" {
+		return nil
+	}
+	return strings.Split(f.Value, valueSeparator)
+}
+
+// Record is an ordered set of fields.
+type Record struct {
+	fields     []Field
+	lineEnding string
+	// unterminated is set when the last line of a parsed record had no line ending.
+	unterminated bool
+}
+
+// New creates an empty record that uses unix line endings.
+func New() *Record {
+	return &Record{lineEnding: "\n"}
+}
+
+// Parse reads a record from r.
+// The line ending of the first line is used for the whole record.
+func Parse(r io.Reader) (*Record, error) {
+	rec := New()
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read line %d: %v", n, err)
+		}
+		if line == "" && err == io.EOF {
+			break
+		}
+		if n == 1 && strings.HasSuffix(line, "\r\n") {
+			rec.lineEnding = "\r\n"
+		}
+		if strings.HasSuffix(line, rec.lineEnding) {
+			line = strings.TrimSuffix(line, rec.lineEnding)
+		} else if err == io.EOF {
+			rec.unterminated = true
+		} else {
+			return nil, fmt.Errorf("line %d does not end with the record line ending", n)
+		}
+		f, perr := parseField(line)
+		if perr != nil {
+			return nil, fmt.Errorf("line %d: %v", n, perr)
+		}
+		rec.fields = append(rec.fields, f)
+		if err == io.EOF {
+			break
+		}
+	}
+	return rec, nil
+}
+
+// ParseFile reads the record stored at path.
+func ParseFile(path string) (*Record, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open record: %v", err)
+	}
+	rec, err := Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse record %s: %v", path, err)
+	}
+	return rec, nil
+}
+
+func parseField(line string) (Field, error) {
+	if !strings.HasSuffix(line, ",") {
+		return Field{}, fmt.Errorf("missing trailing comma in %q", line)
+	}
+	line = strings.TrimSuffix(line, ",")
+	i := strings.Index(line, ",")
+	if i < 0 {
+		return Field{}, fmt.Errorf("missing value in %q", line)
+	}
+	if i == 0 {
+		return Field{}, fmt.Errorf("missing key in %q", line)
+	}
+	return Field{Key: line[:i], Value: line[i+1:]}, nil
+}
+
+// Fields returns a copy of all fields in their record order.
+func (r *Record) Fields() []Field {
+	return append([]Field(nil), r.fields...)
+}
+
+// Keys returns all keys in their record order.
+func (r *Record) Keys() []string {
+	keys := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		keys[i] = f.Key
+	}
+	return keys
+}
+
+func (r *Record) index(key string) int {
+	for i, f := range r.fields {
+		if f.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the raw value of key and whether the key is present.
+func (r *Record) Get(key string) (string, bool) {
+	i := r.index(key)
+	if i < 0 {
+		return "", false
+	}
+	return r.fields[i].Value, true
+}
+
+// Values returns the single values of key.
+func (r *Record) Values(key string) []string {
+	i := r.index(key)
+	if i < 0 {
+		return nil
+	}
+	return r.fields[i].Values()
+}
+
+// Set changes the value of key in place or appends the key if it is new.
+func (r *Record) Set(key, value string) {
+	if i := r.index(key); i >= 0 {
+		r.fields[i].Value = value
+		return
+	}
+	r.fields = append(r.fields, Field{Key: key, Value: value})
+}
+
+// SetValues sets key to a multi value field.
+func (r *Record) SetValues(key string, values ...string) {
+	r.Set(key, strings.Join(values, valueSeparator))
+}
+
+// Delete removes key from the record.
+func (r *Record) Delete(key string) {
+	if i := r.index(key); i >= 0 {
+		r.fields = append(r.fields[:i], r.fields[i+1:]...)
+	}
+}
+
+// Bytes serialises the record into the dbr text format.
+func (r *Record) Bytes() []byte {
+	var b bytes.Buffer
+	for i, f := range r.fields {
+		b.WriteString(f.Key)
+		b.WriteByte(',')
+		b.WriteString(f.Value)
+		b.WriteByte(',')
+		if i < len(r.fields)-1 || !r.unterminated {
+			b.WriteString(r.lineEnding)
+		}
+	}
+	return b.Bytes()
+}
+
+// WriteTo writes the serialised record to w.
+func (r *Record) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(r.Bytes())
+	return int64(n), err
+}
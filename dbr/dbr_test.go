@@ -0,0 +1,162 @@
+package dbr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestParse(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  []Field
+		OK   bool
+	}{
+		{
+			Name: "UnixLineEndings",
+			In:   "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nlootName1,records\\item\\helm.dbr,\n",
+			Out: []Field{
+				{Key: "templateName", Value: "database\\Templates\\LootMasterTable.tpl"},
+				{Key: "ActorName", Value: ""},
+				{Key: "lootName1", Value: "records\\item\\helm.dbr"},
+			},
+			OK: true,
+		},
+		{
+			Name: "WindowsLineEndings",
+			In:   "Class,LootMasterTable,\r\nlootWeight1,100,\r\n",
+			Out: []Field{
+				{Key: "Class", Value: "LootMasterTable"},
+				{Key: "lootWeight1", Value: "100"},
+			},
+			OK: true,
+		},
+		{
+			Name: "MultiValue",
+			In:   "itemNames,a.dbr;b.dbr;c.dbr,\n",
+			Out: []Field{
+				{Key: "itemNames", Value: "a.dbr;b.dbr;c.dbr"},
+			},
+			OK: true,
+		},
+		{
+			Name: "NoTrailingNewline",
+			In:   "Class,LootMasterTable,\nlootWeight1,100,",
+			Out: []Field{
+				{Key: "Class", Value: "LootMasterTable"},
+				{Key: "lootWeight1", Value: "100"},
+			},
+			OK: true,
+		},
+		{
+			Name: "Empty",
+			In:   "",
+			Out:  nil,
+			OK:   true,
+		},
+		{
+			Name: "MissingTrailingComma",
+			In:   "Class,LootMasterTable\n",
+			OK:   false,
+		},
+		{
+			Name: "MissingValue",
+			In:   "Class,\n",
+			OK:   false,
+		},
+		{
+			Name: "MissingKey",
+			In:   ",LootMasterTable,\n",
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := Parse(strings.NewReader(td.In))
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				return
+			}
+			if diff := deep.Equal(r.Fields(), td.Out); diff != nil {
+				t.Errorf("result differs from expected fields: %+v", diff)
+			}
+			if string(r.Bytes()) != td.In {
+				t.Errorf("expected round trip to give %q got %q instead", td.In, r.Bytes())
+			}
+		})
+	}
+}
+
+func TestModify(t *testing.T) {
+	testData := []struct {
+		Name   string
+		In     string
+		Modify func(r *Record)
+		Out    string
+	}{
+		{
+			Name:   "SetExisting",
+			In:     "Class,LootMasterTable,\r\nlootWeight1,100,\r\n",
+			Modify: func(r *Record) { r.Set("Class", "LootItemTable_FixedWeight") },
+			Out:    "Class,LootItemTable_FixedWeight,\r\nlootWeight1,100,\r\n",
+		},
+		{
+			Name:   "SetNew",
+			In:     "Class,LootMasterTable,\n",
+			Modify: func(r *Record) { r.Set("lootWeight1", "50") },
+			Out:    "Class,LootMasterTable,\nlootWeight1,50,\n",
+		},
+		{
+			Name:   "SetValues",
+			In:     "Class,LootItemTable_DynWeight,\n",
+			Modify: func(r *Record) { r.SetValues("itemNames", "a.dbr", "b.dbr") },
+			Out:    "Class,LootItemTable_DynWeight,\nitemNames,a.dbr;b.dbr,\n",
+		},
+		{
+			Name:   "Delete",
+			In:     "Class,LootMasterTable,\nlootWeight1,100,\n",
+			Modify: func(r *Record) { r.Delete("Class") },
+			Out:    "lootWeight1,100,\n",
+		},
+		{
+			Name:   "DeleteMissing",
+			In:     "Class,LootMasterTable,\n",
+			Modify: func(r *Record) { r.Delete("lootWeight1") },
+			Out:    "Class,LootMasterTable,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := Parse(strings.NewReader(td.In))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			td.Modify(r)
+			if string(r.Bytes()) != td.Out {
+				t.Errorf("expected %q got %q instead", td.Out, r.Bytes())
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	r := New()
+	r.Set("itemNames", "a.dbr;b.dbr")
+	r.Set("ActorName", "")
+	if diff := deep.Equal(r.Values("itemNames"), []string{"a.dbr", "b.dbr"}); diff != nil {
+		t.Errorf("result differs from expected values: %+v", diff)
+	}
+	if v := r.Values("ActorName"); v != nil {
+		t.Errorf("expected no values got %v instead", v)
+	}
+	if _, ok := r.Get("lootName1"); ok {
+		t.Error("expected lootName1 to be missing")
+	}
+}
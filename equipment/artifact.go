@@ -0,0 +1,87 @@
+package equipment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ArtifactLoot is an artifact whose formula drops and is sold together with the items of the equipment.
+type ArtifactLoot struct {
+	Name string `yaml:"Name"`
+	// Formula is the arcane formula record that creates the artifact.
+	Formula string `yaml:"Formula"`
+	// Weight is the weight of the formula in the formula table of the equipment.
+	// A missing weight counts as 100.
+	Weight int `yaml:"Weight"`
+	// Bonuses are the completion bonuses the artifact rolls from by their weight when it is created.
+	// If they are set the formula gets copied next to the formula table
+	// with its artifactBonusTableName pointing at them, otherwise the formula keeps its own bonuses.
+	Bonuses []WeightedRecord `yaml:"Bonuses"`
+}
+
+// Validate validates the artifact configuration.
+func (a *ArtifactLoot) Validate() error {
+	if a.Formula == "" {
+		return fmt.Errorf("formula record is missing")
+	}
+	if err := validateWeighted([]WeightedRecord{{Record: a.Formula, Weight: a.Weight}}, "formula"); err != nil {
+		return err
+	}
+	return validateWeighted(a.Bonuses, "completion bonus")
+}
+
+// records returns all records the artifact references.
+func (a *ArtifactLoot) records() []string {
+	records := []string{a.Formula}
+	for _, bonus := range a.Bonuses {
+		records = append(records, bonus.Record)
+	}
+	return records
+}
+
+// formulaTablePath returns the path of the table dropping the formulae of all artifacts relative to FolderPath.
+func (e *Equipment) formulaTablePath() string {
+	return filepath.Join(e.TablePath, "artifacts", "formulaTable.dbr")
+}
+
+// createArtifacts writes the formula table of the equipment along with the completion bonus tables
+// and copies of all formulae that roll from them.
+func (e *Equipment) createArtifacts() error {
+	if len(e.Artifacts) == 0 {
+		return nil
+	}
+	folder := filepath.Dir(e.formulaTablePath())
+	if err := os.MkdirAll(filepath.Join(e.FolderPath, folder), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", folder, err)
+	}
+	var formulae []WeightedRecord
+	for n, artifact := range e.Artifacts {
+		record := artifact.Formula
+		if len(artifact.Bonuses) > 0 {
+			record = filepath.Join(folder, fmt.Sprintf("formula%d.dbr", n+1))
+			bonusPath := filepath.Join(folder, fmt.Sprintf("formula%dBonusTable.dbr", n+1))
+			if err := e.copyWithBonuses(artifact.Formula, "artifactBonusTableName", record, bonusPath, artifact.Bonuses); err != nil {
+				return err
+			}
+		}
+		formulae = append(formulae, WeightedRecord{Name: artifact.Name, Record: record, Weight: artifact.Weight})
+	}
+	formulaTable, err := createLootTable(e.formulaTablePath(), formulae)
+	if err != nil {
+		return fmt.Errorf("failed to initialise %s: %v", formulaTable.Path, err)
+	}
+	if err := formulaTable.write(e.FolderPath); err != nil {
+		return fmt.Errorf("failed to write table to %s: %v", formulaTable.Path, err)
+	}
+	return nil
+}
+
+// artifactNames joins the names of all artifacts to describe the formula table.
+func (e *Equipment) artifactNames() string {
+	var artifacts []WeightedRecord
+	for _, a := range e.Artifacts {
+		artifacts = append(artifacts, WeightedRecord{Name: a.Name})
+	}
+	return recordNames(artifacts)
+}
@@ -0,0 +1,78 @@
+package equipment
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestFlushArtifacts(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Artifacts: []ArtifactLoot{
+			{
+				Name:    "TestArtifact",
+				Formula: "records/item/artifacts/arcaneformulae/testformula.dbr",
+				Bonuses: []WeightedRecord{{Record: "Test/Bonus/life.dbr", Weight: 10}},
+			},
+			{Name: "VanillaArtifact", Formula: "records/item/artifacts/arcaneformulae/vanillaformula.dbr", Weight: 25},
+		},
+		db: db,
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "FormulaTable",
+			In:   "records/test_equip/artifacts/formulaTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestArtifact/VanillaArtifact,\n" +
+				"lootName1," + filepath.Join("records", "test_equip", "artifacts", "formula1.dbr") + ",\nlootWeight1,100,\n" +
+				"lootName2,records/item/artifacts/arcaneformulae/vanillaformula.dbr,\nlootWeight2,25,\n",
+		},
+		{
+			Name: "BonusTable",
+			In:   "records/test_equip/artifacts/formula1BonusTable.dbr",
+			Out: "templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,,\n" +
+				"randomizerName1,Test/Bonus/life.dbr,\nrandomizerWeight1,10,\n",
+		},
+		{
+			Name: "CopiedFormula",
+			In:   "records/test_equip/artifacts/formula1.dbr",
+			Out: "templateName,database\\Templates\\ItemArtifactFormula.tpl,\nClass,ItemArtifactFormula,\nFileDescription,Test formula,\n" +
+				"artifactName,records\\item\\artifacts\\testartifact.dbr,\n" +
+				"artifactBonusTableName," + filepath.Join("records", "test_equip", "artifacts", "formula1BonusTable.dbr") + ",\n" +
+				"reagentBaseBaseName,records\\item\\relics\\testrelic.dbr,\n",
+		},
+		{
+			Name: "EquipmentMerchantTable",
+			In:   "records/test_equip/merchantTable.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestEquipment,\n" +
+				"lootName1," + filepath.Join("records", "test_equip", "artifacts", "formulaTable.dbr") + ",\nlootWeight1,100,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			rec, err := dbr.ParseFile(filepath.Join(e.FolderPath, filepath.FromSlash(td.In)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(string(rec.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected record: %+v", diff)
+			}
+		})
+	}
+}
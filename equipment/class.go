@@ -0,0 +1,144 @@
+package equipment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+)
+
+// affixFolder holds all prefixes, suffixes and the randomizer tables that group them.
+const affixFolder = `records\item\lootmagicalaffixes`
+
+// affixClass is the class of all prefixes, suffixes and completion bonuses.
+const affixClass = "LootRandomizer"
+
+// Item categories affixes are restricted to.
+const (
+	weaponCategory    = "weapon"
+	shieldCategory    = "shield"
+	armorCategory     = "armor"
+	jewelleryCategory = "jewellery"
+)
+
+// tableCategories maps words in the path of the game's randomizer tables to the item category the table is made for.
+// The first match wins so the more specific words come first.
+var tableCategories = []struct {
+	word     string
+	category string
+}{
+	{"shield", shieldCategory},
+	{"jewel", jewelleryCategory},
+	{"amulet", jewelleryCategory},
+	{"ring", jewelleryCategory},
+	{"armor", armorCategory},
+	{"weapon", weaponCategory},
+	{"sword", weaponCategory},
+	{"axe", weaponCategory},
+	{"mace", weaponCategory},
+	{"club", weaponCategory},
+	{"spear", weaponCategory},
+	{"staff", weaponCategory},
+	{"bow", weaponCategory},
+	{"thrown", weaponCategory},
+}
+
+// categoryOfClass returns the item category of a record class like WeaponMelee_Sword.
+// Classes that are no equipment have no category.
+func categoryOfClass(class string) string {
+	switch {
+	case class == shieldClass:
+		return shieldCategory
+	case strings.HasPrefix(class, "Weapon"):
+		return weaponCategory
+	case strings.HasPrefix(class, "ArmorJewelry"):
+		return jewelleryCategory
+	case strings.HasPrefix(class, "ArmorProtective"):
+		return armorCategory
+	}
+	return ""
+}
+
+// categoryOfTable returns the item category a randomizer table of the game is made for.
+func categoryOfTable(path string) string {
+	path = strings.TrimPrefix(arz.CleanPath(path), affixFolder)
+	for _, c := range tableCategories {
+		if strings.Contains(path, c.word) {
+			return c.category
+		}
+	}
+	return ""
+}
+
+// affixCategories returns the item categories every affix of the game can roll on.
+// An affix can roll on a category when a randomizer table of the category lists it.
+// Affixes that are in no such table are missing in the result and are not restricted.
+func (e *Equipment) affixCategories() (map[string]map[string]bool, error) {
+	if e.affixes != nil {
+		return e.affixes, nil
+	}
+	affixes, err := readAffixCategories(e.db)
+	if err != nil {
+		return nil, err
+	}
+	e.affixes = affixes
+	return affixes, nil
+}
+
+// readAffixCategories reads the item categories of all affixes from the randomizer tables of the database.
+func readAffixCategories(db *arz.Archive) (map[string]map[string]bool, error) {
+	affixes := make(map[string]map[string]bool)
+	for _, path := range db.List(affixFolder) {
+		if class, _ := db.Class(path); class != affixTableClass {
+			continue
+		}
+		category := categoryOfTable(path)
+		if category == "" {
+			continue
+		}
+		table, err := db.Record(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range table.Fields() {
+			if !strings.HasPrefix(f.Key, "randomizerName") || f.Value == "" {
+				continue
+			}
+			affix := arz.CleanPath(f.Value)
+			if affixes[affix] == nil {
+				affixes[affix] = make(map[string]bool)
+			}
+			affixes[affix][category] = true
+		}
+	}
+	return affixes, nil
+}
+
+// verifyAffixes checks that the prefixes, suffixes and broken affixes of an item can roll on all of its base items.
+func (e *Equipment) verifyAffixes(i Item) error {
+	affixes, err := e.affixCategories()
+	if err != nil {
+		return fmt.Errorf("failed to read affix tables: %v", err)
+	}
+	for _, base := range i.bases() {
+		class, _ := e.db.Class(base.Record)
+		category := categoryOfClass(class)
+		if category == "" {
+			continue
+		}
+		for _, affix := range i.affixes() {
+			categories, ok := affixes[arz.CleanPath(affix.Record)]
+			if !ok || categories[category] {
+				continue
+			}
+			var allowed []string
+			for c := range categories {
+				allowed = append(allowed, c)
+			}
+			sort.Strings(allowed)
+			return fmt.Errorf("%s cannot roll on %s items like %s, only on %s", affix.Record, category, base.Record, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package equipment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Difficulty is a variant of the equipment for one difficulty of the game like Epic or Legendary.
+// Its tables are written below TablePath/Name next to the ones of the equipment
+// and it gets its own merchant table.
+type Difficulty struct {
+	Name string `yaml:"Name"`
+	// Merchants are merchant NPC records that get patched to sell from the merchant table of the difficulty.
+	Merchants []string `yaml:"Merchants"`
+	// Items override the equipment item with the same slot.
+	// Every field that is not set keeps the one of the equipment item,
+	// so a level range needs its TableType and broken chances need their BrokenAffixes.
+	// Items of slots the equipment has no item for are added.
+	Items []Item `yaml:"Items"`
+}
+
+// Validate validates the difficulty configuration.
+func (d *Difficulty) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("difficulty name is missing")
+	}
+	if alphanumeric(d.Name) != d.Name {
+		return fmt.Errorf("difficulty name %s may only contain letters and digits", d.Name)
+	}
+	for _, folder := range append(slotNames(), "artifacts", "containers", "quests") {
+		if strings.EqualFold(d.Name, folder) {
+			return fmt.Errorf("difficulty name %s is used by the tables of the equipment", d.Name)
+		}
+	}
+	for _, i := range d.Items {
+		if err := i.Validate(); err != nil {
+			return fmt.Errorf("item %s is not valid: %v", i.BaseName, err)
+		}
+	}
+	return nil
+}
+
+// items returns the items of the equipment with the overrides of the difficulty.
+func (d *Difficulty) items(items []Item) []Item {
+	var merged []Item
+	overridden := make(map[Slot]bool)
+	for _, item := range items {
+		for _, o := range d.Items {
+			if o.SlotIdentifier == item.SlotIdentifier {
+				item = item.withOverrides(o)
+				overridden[o.SlotIdentifier] = true
+			}
+		}
+		merged = append(merged, item)
+	}
+	for _, o := range d.Items {
+		if !overridden[o.SlotIdentifier] {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
+
+// withOverrides returns the item with the fields of o that are set.
+// The table type comes with its level range, the broken chances with their affixes.
+func (i Item) withOverrides(o Item) Item {
+	if len(o.bases()) > 0 {
+		i.BaseName, i.BaseRecord, i.Bases = o.BaseName, o.BaseRecord, o.Bases
+	}
+	if len(o.prefixes()) > 0 {
+		i.PrefixName, i.PrefixRecord, i.Prefixes = o.PrefixName, o.PrefixRecord, o.Prefixes
+	}
+	if len(o.suffixes()) > 0 {
+		i.SuffixName, i.SuffixRecord, i.Suffixes = o.SuffixName, o.SuffixRecord, o.Suffixes
+	}
+	if o.TableType != "" {
+		i.TableType, i.MinLevel, i.MaxLevel = o.TableType, o.MinLevel, o.MaxLevel
+	}
+	if o.BothPrefixSuffix != nil {
+		i.BothPrefixSuffix = o.BothPrefixSuffix
+	}
+	if o.PrefixChance != nil {
+		i.PrefixChance = o.PrefixChance
+	}
+	if o.SuffixChance != nil {
+		i.SuffixChance = o.SuffixChance
+	}
+	if o.broken() {
+		i.BrokenOnly, i.BrokenChance, i.BrokenAffixes = o.BrokenOnly, o.BrokenChance, o.BrokenAffixes
+	}
+	if len(o.Relics) > 0 {
+		i.Relics = o.Relics
+	}
+	if o.MerchantWeight > 0 {
+		i.MerchantWeight = o.MerchantWeight
+	}
+	return i
+}
+
+// difficulty returns the variant of the equipment for a difficulty.
+func (e *Equipment) difficulty(d Difficulty) *Equipment {
+	variant := *e
+	variant.TablePath = filepath.Join(e.TablePath, d.Name)
+	variant.Merchants = d.Merchants
+	variant.Items = d.items(e.Items)
+	variant.Difficulties = nil
+	return &variant
+}
+
+// allItems returns the items of the equipment and of all difficulties.
+func (e *Equipment) allItems() []Item {
+	items := append([]Item(nil), e.Items...)
+	for _, d := range e.Difficulties {
+		items = append(items, e.difficulty(d).Items...)
+	}
+	return items
+}
+
+// createDifficulties writes the tables of every difficulty
+// and patches the merchants of the difficulty to sell from its merchant table.
+func (e *Equipment) createDifficulties() error {
+	for _, d := range e.Difficulties {
+		variant := e.difficulty(d)
+		if err := os.MkdirAll(filepath.Join(variant.FolderPath, variant.TablePath), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", variant.TablePath, err)
+		}
+		if err := variant.createItems(); err != nil {
+			return fmt.Errorf("failed to create difficulty %s: %v", d.Name, err)
+		}
+		if err := variant.patchMerchants(); err != nil {
+			return fmt.Errorf("failed to create difficulty %s: %v", d.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package equipment
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestDifficultyItems(t *testing.T) {
+	half := 50.0
+	items := []Item{
+		{
+			SlotIdentifier: Amulet,
+			BaseName:       "TestAmulet",
+			BaseRecord:     "Test/BaseRecord/amulet.dbr",
+			PrefixRecord:   "Test/PrefixRecord/normal.dbr",
+			SuffixRecord:   "Test/SuffixRecord/normal.dbr",
+			MerchantWeight: 10,
+		},
+		{SlotIdentifier: Head, BaseRecord: "Test/BaseRecord/helm.dbr"},
+	}
+	testData := []struct {
+		Name string
+		In   Difficulty
+		Out  []Item
+	}{
+		{
+			Name: "NoOverrides",
+			In:   Difficulty{Name: "Epic"},
+			Out:  items,
+		},
+		{
+			Name: "OverrideAffixes",
+			In: Difficulty{Name: "Epic", Items: []Item{
+				{SlotIdentifier: Amulet, Prefixes: []WeightedRecord{{Record: "Test/PrefixRecord/epic.dbr"}}},
+			}},
+			Out: []Item{
+				{
+					SlotIdentifier: Amulet,
+					BaseName:       "TestAmulet",
+					BaseRecord:     "Test/BaseRecord/amulet.dbr",
+					Prefixes:       []WeightedRecord{{Record: "Test/PrefixRecord/epic.dbr"}},
+					SuffixRecord:   "Test/SuffixRecord/normal.dbr",
+					MerchantWeight: 10,
+				},
+				items[1],
+			},
+		},
+		{
+			Name: "OverrideBaseAndAddSlot",
+			In: Difficulty{Name: "Legendary", Items: []Item{
+				{SlotIdentifier: Amulet, BaseName: "LegendaryAmulet", BaseRecord: "Test/BaseRecord/legendary.dbr"},
+				{SlotIdentifier: Torso, BaseRecord: "Test/BaseRecord/torso.dbr"},
+			}},
+			Out: []Item{
+				{
+					SlotIdentifier: Amulet,
+					BaseName:       "LegendaryAmulet",
+					BaseRecord:     "Test/BaseRecord/legendary.dbr",
+					PrefixRecord:   "Test/PrefixRecord/normal.dbr",
+					SuffixRecord:   "Test/SuffixRecord/normal.dbr",
+					MerchantWeight: 10,
+				},
+				items[1],
+				{SlotIdentifier: Torso, BaseRecord: "Test/BaseRecord/torso.dbr"},
+			},
+		},
+		{
+			Name: "OverrideTableAndChances",
+			In: Difficulty{Name: "Epic", Items: []Item{
+				{
+					SlotIdentifier: Head,
+					TableType:      DynWeight,
+					MinLevel:       30,
+					MaxLevel:       40,
+					PrefixChance:   &half,
+					BrokenChance:   10,
+					BrokenAffixes:  []WeightedRecord{{Record: "Test/BrokenRecord/epic.dbr"}},
+					Relics:         []RelicLoot{{Record: "Test/Relic/epic.dbr"}},
+					MerchantWeight: 20,
+				},
+			}},
+			Out: []Item{
+				items[0],
+				{
+					SlotIdentifier: Head,
+					BaseRecord:     "Test/BaseRecord/helm.dbr",
+					TableType:      DynWeight,
+					MinLevel:       30,
+					MaxLevel:       40,
+					PrefixChance:   &half,
+					BrokenChance:   10,
+					BrokenAffixes:  []WeightedRecord{{Record: "Test/BrokenRecord/epic.dbr"}},
+					Relics:         []RelicLoot{{Record: "Test/Relic/epic.dbr"}},
+					MerchantWeight: 20,
+				},
+			},
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			if diff := deep.Equal(td.In.items(items), td.Out); diff != nil {
+				t.Errorf("result differs from expected items: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestFlushDifficulties(t *testing.T) {
+	folder := t.TempDir()
+	merchant := filepath.Join("records", "creature", "npc", "merchant", "epicmerchant.dbr")
+	if err := os.MkdirAll(filepath.Join(folder, filepath.Dir(merchant)), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rec := dbr.New()
+	rec.Set("Class", "Merchant")
+	if err := ioutil.WriteFile(filepath.Join(folder, merchant), rec.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: folder,
+		TablePath:  "records/test_equip",
+		Difficulties: []Difficulty{
+			{
+				Name:      "Epic",
+				Merchants: []string{"records/creature/npc/merchant/epicmerchant.dbr"},
+				Items: []Item{
+					{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/epic.dbr"},
+				},
+			},
+		},
+		Items: []Item{
+			{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/amulet.dbr"},
+		},
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "NormalItemTable",
+			In:   "records/test_equip/Amulet/itemTable.dbr",
+			Out:  "lootName1,Test/BaseRecord/amulet.dbr,\n",
+		},
+		{
+			Name: "EpicItemTable",
+			In:   "records/test_equip/Epic/Amulet/itemTable.dbr",
+			Out:  "lootName1,Test/BaseRecord/epic.dbr,\n",
+		},
+		{
+			Name: "EpicMerchantTable",
+			In:   "records/test_equip/Epic/merchantTable.dbr",
+			Out:  "lootName1," + filepath.Join("records", "test_equip", "Epic", "Amulet", "itemTable.dbr") + ",\n",
+		},
+		{
+			Name: "EpicMerchant",
+			In:   "records/creature/npc/merchant/epicmerchant.dbr",
+			Out:  "marketTableName," + filepath.Join("records", "test_equip", "Epic", "merchantTable.dbr") + ",\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			rec, err := dbr.ParseFile(filepath.Join(folder, filepath.FromSlash(td.In)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(rec.Bytes()), td.Out) {
+				t.Errorf("expected %s to contain %q:\n%s", td.In, td.Out, rec.Bytes())
+			}
+		})
+	}
+}
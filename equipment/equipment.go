@@ -3,9 +3,17 @@ package equipment
 import (
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
 
+	"github.com/Deichindianer/tq-item-setup/arc"
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/Deichindianer/tq-item-setup/tags"
 	"github.com/go-yaml/yaml"
 )
 
@@ -14,25 +22,20 @@ const (
 	affixTableTemplate    = "database\\Templates\\LootRandomizerTable.tpl"
 	itemTableClass        = "LootItemTable_FixedWeight"
 	itemTableTemplate     = "database\\Templates\\LootItemTable_FixedWeight.tpl"
+	dynItemTableClass     = "LootItemTable_DynWeight"
+	dynItemTableTemplate  = "database\\Templates\\LootItemTable_DynWeight.tpl"
 	merchantTableClass    = "LootMasterTable"
 	merchantTableTemplate = "database\\Templates\\LootMasterTable.tpl"
+	containerClass        = "FixedItemContainer"
+	containerTemplate     = "database\\Templates\\FixedItemContainer.tpl"
 )
 
-// Constants for all the item slots.
-// They can be converted to and from strings.
-// UnknownSlot is treated as an invalid slot and used in error cases.
+// Item table types.
+// FixedWeight tables drop their bases by weight,
+// DynWeight tables drop the bases closest to the target level.
 const (
-	// I know this could be iota but this is way more readable :)
-	UnknownSlot = -1
-	Amulet      = 0
-	Arm         = 1
-	Head        = 2
-	Leg         = 3
-	RingLeft    = 4
-	RingRight   = 5
-	Torso       = 6
-	WeaponLeft  = 7
-	WeaponRight = 8
+	FixedWeight = "FixedWeight"
+	DynWeight   = "DynWeight"
 )
 
 // Equipment is an entire equipment of a Titan Quest char plus all metadata for filesystem storage
@@ -40,77 +43,134 @@ type Equipment struct {
 	Name       string `yaml:"Name"`
 	FolderPath string `yaml:"FolderPath"`
 	TablePath  string `yaml:"TablePath"`
-	Items      []Item `yaml:"Items"`
+	// DatabasePath points at the database.arz of the installed game.
+	// If it is set all referenced records are checked against it.
+	DatabasePath string `yaml:"DatabasePath"`
+	// TextPath points at the text archive of the installed game like Text_EN.arc.
+	// Together with DatabasePath it resolves BaseName, PrefixName and SuffixName
+	// as well as the names of Bases, Prefixes and Suffixes without a record into record paths.
+	// Without it the names are only labels.
+	TextPath string `yaml:"TextPath"`
+	// Strict turns the warnings about the equipment into errors.
+	Strict bool `yaml:"Strict"`
+	// CompleteSets adds an item for every piece that is missing from the sets of the base records.
+	// It needs DatabasePath to read the sets.
+	CompleteSets bool `yaml:"CompleteSets"`
+	// Level, Strength, Dexterity and Intelligence are the budget of the character that wears the equipment.
+	// Items that can drop with higher requirements are reported.
+	// A budget of 0 is not checked and all of them need DatabasePath to read the requirements.
+	Level        int `yaml:"Level"`
+	Strength     int `yaml:"Strength"`
+	Dexterity    int `yaml:"Dexterity"`
+	Intelligence int `yaml:"Intelligence"`
+	// Masteries are the masteries of the character that wears the equipment.
+	// If they are set dual wielding without the Warfare mastery is reported.
+	Masteries []string `yaml:"Masteries"`
+	// OutputDatabasePath is where Flush compiles all records below FolderPath
+	// and RecordFolders into a database.arz the game can load.
+	// Nothing is compiled if it is empty.
+	OutputDatabasePath string   `yaml:"OutputDatabasePath"`
+	RecordFolders      []string `yaml:"RecordFolders"`
+	// OutputTextPath is where Flush packs the name tags of the bases and Tags into a text archive like Text_EN.arc.
+	// Bases with a name are copied next to the tables of their item with their itemNameTag pointing at the name tag,
+	// so the game shows their name. Nothing is packed or copied if it is empty.
+	OutputTextPath string            `yaml:"OutputTextPath"`
+	Tags           map[string]string `yaml:"Tags"`
+	// Merchants are merchant NPC records that get copied into FolderPath
+	// and patched to sell from the merchant table of the equipment.
+	Merchants []string `yaml:"Merchants"`
+	// Monsters are monster records that get copied into FolderPath and patched to equip the items.
+	Monsters []MonsterLoot `yaml:"Monsters"`
+	// Containers are chests that drop the items.
+	Containers []Container `yaml:"Containers"`
+	// Artifacts are sold and dropped by their formulae together with the items.
+	Artifacts []ArtifactLoot `yaml:"Artifacts"`
+	// Difficulties are variants of the items with their own tables and merchant table.
+	Difficulties []Difficulty `yaml:"Difficulties"`
+	// Quests offer a choice between the items as quest reward.
+	Quests []Quest `yaml:"Quests"`
+	// Items get their tables in a folder per slot below TablePath.
+	Items []Item `yaml:"Items"`
+
+	db      *arz.Archive
+	affixes map[string]map[string]bool
 }
 
 // Item holds all references to item configuration.
 // Also this is used to represent the config structure.
 type Item struct {
-	SlotIdentifier string `yaml:"SlotIdentifier"`
+	SlotIdentifier Slot   `yaml:"SlotIdentifier"`
 	BaseName       string `yaml:"BaseName"`
 	BaseRecord     string `yaml:"BaseRecord"`
 	PrefixName     string `yaml:"PrefixName"`
 	PrefixRecord   string `yaml:"PrefixRecord"`
 	SuffixName     string `yaml:"SuffixName"`
 	SuffixRecord   string `yaml:"SuffixRecord"`
+	// Prefixes and Suffixes are rolled by their weight.
+	// They are used in addition to PrefixRecord and SuffixRecord.
+	Prefixes []WeightedRecord `yaml:"Prefixes"`
+	Suffixes []WeightedRecord `yaml:"Suffixes"`
+	// Bases are dropped by their weight in addition to BaseRecord.
+	Bases []WeightedRecord `yaml:"Bases"`
+	// TableType is FixedWeight if it is empty.
+	// DynWeight tables ignore the weight of the bases and only drop bases
+	// with an item level between MinLevel and MaxLevel if they are set.
+	// FixedWeight tables have no level range.
+	TableType string `yaml:"TableType"`
+	MinLevel  int    `yaml:"MinLevel"`
+	MaxLevel  int    `yaml:"MaxLevel"`
+	// BothPrefixSuffix, PrefixChance and SuffixChance are percentages that default to 100.
+	BothPrefixSuffix *float64 `yaml:"BothPrefixSuffix"`
+	PrefixChance     *float64 `yaml:"PrefixChance"`
+	SuffixChance     *float64 `yaml:"SuffixChance"`
+	// BrokenOnly makes every dropped item broken.
+	// Otherwise BrokenChance is the percentage of broken items.
+	// Broken items roll one of the BrokenAffixes instead of a prefix or suffix.
+	BrokenOnly    bool             `yaml:"BrokenOnly"`
+	BrokenChance  float64          `yaml:"BrokenChance"`
+	BrokenAffixes []WeightedRecord `yaml:"BrokenAffixes"`
+	// Relics are relics and charms that drop and are sold together with the item.
+	Relics []RelicLoot `yaml:"Relics"`
+	// MerchantWeight is the weight of the item in the merchant table of the whole equipment.
+	// A missing weight counts as 100.
+	MerchantWeight int `yaml:"MerchantWeight"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler so items without a SlotIdentifier
+// get UnknownSlot instead of the zero value Amulet and fail to validate.
+func (i *Item) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Item
+	p := plain{SlotIdentifier: UnknownSlot}
+	if err := unmarshal(&p); err != nil {
+		return err
+	}
+	*i = Item(p)
+	return nil
+}
+
+// MonsterLoot makes a monster equip and drop the items of the equipment.
+// The other loot of the patched slots is cleared, so the monster drops nothing else in them.
+type MonsterLoot struct {
+	Record string `yaml:"Record"`
+	// Chance is the percentage that the monster equips the item of a slot.
+	// It defaults to 100.
+	Chance *float64 `yaml:"Chance"`
+	// Slots limits the items the monster equips to the ones of these slots.
+	// All items are equipped if it is empty.
+	Slots []Slot `yaml:"Slots"`
 }
 
-// Slot is the slot where the equipment goes, lol
-type Slot int
-
-func (i Slot) String() string {
-	switch i {
-	case Amulet:
-		return "Amulet"
-	case Arm:
-		return "Arm"
-	case Head:
-		return "Head"
-	case Leg:
-		return "Leg"
-	case RingLeft:
-		return "RingLeft"
-	case RingRight:
-		return "RingRight"
-	case Torso:
-		return "Torso"
-	case WeaponLeft:
-		return "WeaponLeft"
-	case WeaponRight:
-		return "WeaponRight"
-	}
-	return ""
-}
-
-// SlotFromString converts a string representation of a slot into its constant value.
-func SlotFromString(s string) (Slot, error) {
-	switch s {
-	case "Amulet":
-		return Amulet, nil
-	case "Arm":
-		return Arm, nil
-	case "Head":
-		return Head, nil
-	case "Leg":
-		return Leg, nil
-	case "RingLeft":
-		return RingLeft, nil
-	case "RingRight":
-		return RingRight, nil
-	case "Torso":
-		return Torso, nil
-	case "WeaponLeft":
-		return WeaponLeft, nil
-	case "WeaponRight":
-		return WeaponRight, nil
-	}
-	return UnknownSlot, fmt.Errorf("unexpectected Slot %s", s)
+// WeightedRecord is a record that is picked from a table by its weight.
+// A missing weight counts as 100.
+type WeightedRecord struct {
+	Name   string `yaml:"Name"`
+	Record string `yaml:"Record"`
+	Weight int    `yaml:"Weight"`
 }
 
 type table struct {
-	Path    string
-	Headers []byte
-	Body    []byte
+	Path   string
+	Record *dbr.Record
 }
 
 // New creates the memory construct for the table bases
@@ -124,25 +184,333 @@ func New(name, folderPath string) (*Equipment, error) {
 
 // Flush creates the entire representation of the equipment in the filesystem.
 func (e *Equipment) Flush() error {
-	if err := os.MkdirAll(filepath.Join(e.FolderPath, e.TablePath), 0644); err != nil {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(e.FolderPath, e.TablePath), 0755); err != nil {
 		return fmt.Errorf("failed to create %s: %v", e.FolderPath, err)
 	}
 	if err := e.createItems(); err != nil {
 		return err
 	}
+	if err := e.patchMerchants(); err != nil {
+		return err
+	}
+	if err := e.patchMonsters(); err != nil {
+		return err
+	}
+	if err := e.createContainers(); err != nil {
+		return err
+	}
+	if err := e.createQuestRewards(); err != nil {
+		return err
+	}
+	if err := e.createDifficulties(); err != nil {
+		return err
+	}
+	if e.OutputDatabasePath != "" {
+		if err := e.compile(); err != nil {
+			return err
+		}
+	}
+	if e.OutputTextPath != "" {
+		if err := e.packText(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// packText writes the text archive holding one tag file for the equipment.
+func (e *Equipment) packText() error {
+	t, err := e.nameTags()
+	if err != nil {
+		return err
+	}
+	for k, v := range e.Tags {
+		t[k] = v
+	}
+	w := arc.NewWriter()
+	if err := w.Add(e.textFileName(), t.Bytes()); err != nil {
+		return err
+	}
+	return w.WriteFile(e.OutputTextPath)
+}
+
+// nameTags returns the name tags of the named bases of all items.
+// It fails if two different names end up with the same tag.
+func (e *Equipment) nameTags() (tags.Tags, error) {
+	t := make(tags.Tags)
+	for _, item := range e.allItems() {
+		for _, base := range item.bases() {
+			if base.Name == "" {
+				continue
+			}
+			tag := e.nameTag(base.Name)
+			if name, ok := t[tag]; ok && name != base.Name {
+				return nil, fmt.Errorf("base names %q and %q share the tag %s", name, base.Name, tag)
+			}
+			t[tag] = base.Name
+		}
+	}
+	return t, nil
+}
+
+// nameTag is the tag holding the name of a base.
+func (e *Equipment) nameTag(name string) string {
+	return "tag" + alphanumeric(e.Name) + alphanumeric(name)
+}
+
+// nameBases copies the named bases of an item next to its tables with their itemNameTag pointing at their name tag.
+// It returns the item with the copies as bases or the item itself if no text is packed.
+func (e *Equipment) nameBases(item Item) (Item, error) {
+	if e.OutputTextPath == "" {
+		return item, nil
+	}
+	n := 0
+	copyBase := func(name, record string) (string, error) {
+		n++
+		if name == "" {
+			return record, nil
+		}
+		rec, err := e.Record(record)
+		if err != nil {
+			return "", fmt.Errorf("failed to load base %s: %v", record, err)
+		}
+		rec.Set("itemNameTag", e.nameTag(name))
+		copied := table{
+			Path:   e.slotTablePath(item, fmt.Sprintf("base%d.dbr", n)),
+			Record: rec,
+		}
+		if err := copied.write(e.FolderPath); err != nil {
+			return "", fmt.Errorf("failed to write base to %s: %v", copied.Path, err)
+		}
+		return copied.Path, nil
+	}
+	var err error
+	if item.BaseRecord != "" {
+		if item.BaseRecord, err = copyBase(item.BaseName, item.BaseRecord); err != nil {
+			return item, err
+		}
+	}
+	bases := make([]WeightedRecord, len(item.Bases))
+	for k, base := range item.Bases {
+		if base.Record, err = copyBase(base.Name, base.Record); err != nil {
+			return item, err
+		}
+		bases[k] = base
+	}
+	item.Bases = bases
+	return item, nil
+}
+
+func (e *Equipment) textFileName() string {
+	return strings.ToLower(alphanumeric(e.Name)) + ".txt"
+}
+
+func alphanumeric(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// compile builds the output database from the written tables and all user provided records.
+// Records below FolderPath and earlier RecordFolders shadow the ones at the same path in later folders,
+// the same way Record loads them.
+func (e *Equipment) compile() error {
+	w := arz.NewWriter()
+	for _, folder := range append([]string{e.FolderPath}, e.RecordFolders...) {
+		if err := w.MergeDir(folder); err != nil {
+			return fmt.Errorf("failed to compile records in %s: %v", folder, err)
+		}
+	}
+	return w.WriteFile(e.OutputDatabasePath)
+}
+
 // Validate validates an item.
-// Currently this only checks if the slot identifier is valid.
+// It checks the slot identifier, the table type and its level range, the chances,
+// that broken items have affixes to roll, the weights of the bases and affixes,
+// the merchant weight and the relics.
 func (i *Item) Validate() error {
-	_, err := SlotFromString(i.SlotIdentifier)
-	if err != nil {
+	if err := i.SlotIdentifier.validate(); err != nil {
 		return err
 	}
+	switch i.TableType {
+	case "", FixedWeight, DynWeight:
+	default:
+		return fmt.Errorf("unexpected TableType %s", i.TableType)
+	}
+	if i.MinLevel < 0 || i.MaxLevel < 0 || (i.MaxLevel > 0 && i.MaxLevel < i.MinLevel) {
+		return fmt.Errorf("level range %d-%d is not valid", i.MinLevel, i.MaxLevel)
+	}
+	if i.TableType != DynWeight && (i.MinLevel > 0 || i.MaxLevel > 0) {
+		return fmt.Errorf("level range %d-%d needs a %s table", i.MinLevel, i.MaxLevel, DynWeight)
+	}
+	for name, chance := range map[string]float64{
+		"BothPrefixSuffix": percentage(i.BothPrefixSuffix),
+		"PrefixChance":     percentage(i.PrefixChance),
+		"SuffixChance":     percentage(i.SuffixChance),
+		"BrokenChance":     i.BrokenChance,
+	} {
+		if chance < 0 || chance > 100 {
+			return fmt.Errorf("%s %v is not a percentage", name, chance)
+		}
+	}
+	if (i.BrokenOnly || i.BrokenChance > 0) && len(i.BrokenAffixes) == 0 {
+		return fmt.Errorf("broken items need BrokenAffixes")
+	}
+	for owner, records := range map[string][]WeightedRecord{
+		"base":         i.Bases,
+		"prefix":       i.Prefixes,
+		"suffix":       i.Suffixes,
+		"broken affix": i.BrokenAffixes,
+	} {
+		if err := validateWeighted(records, owner); err != nil {
+			return err
+		}
+	}
+	if i.MerchantWeight < 0 {
+		return fmt.Errorf("MerchantWeight %d is negative", i.MerchantWeight)
+	}
+	for _, r := range i.Relics {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Validate checks that the weapons of the equipment and of all difficulties can be wielded together,
+// that every quest offers items and that the names of their bases get distinct tags.
+func (e *Equipment) Validate() error {
+	for _, m := range e.Masteries {
+		if err := validateMastery(m); err != nil {
+			return err
+		}
+	}
+	variants := []*Equipment{e}
+	for _, d := range e.Difficulties {
+		variants = append(variants, e.difficulty(d))
+	}
+	for _, v := range variants {
+		if conflicts := v.handConflicts(); len(conflicts) > 0 {
+			return fmt.Errorf("equipment %s has conflicting weapons: %s", v.TablePath, strings.Join(conflicts, "; "))
+		}
+		if err := v.verifyDualWield(); err != nil {
+			return err
+		}
+	}
+	if err := e.verifyQuests(); err != nil {
+		return err
+	}
+	if e.OutputTextPath != "" {
+		if _, err := e.nameTags(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates the monster loot configuration.
+func (m *MonsterLoot) Validate() error {
+	if m.Record == "" {
+		return fmt.Errorf("monster record is missing")
+	}
+	if chance := percentage(m.Chance); chance < 0 || chance > 100 {
+		return fmt.Errorf("Chance %v is not a percentage", chance)
+	}
+	return validateSlots(m.Slots)
+}
+
+// percentage returns the configured percentage or 100 if it is not set.
+func percentage(p *float64) float64 {
+	if p == nil {
+		return 100
+	}
+	return *p
+}
+
+// broken reports whether the item table needs the broken item fields.
+func (i *Item) broken() bool {
+	return i.BrokenOnly || i.BrokenChance > 0 || len(i.BrokenAffixes) > 0
+}
+
+// description is the generated name of an item.
+func (i *Item) description() string {
+	return strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", recordNames(i.prefixes()), recordNames(i.bases()), recordNames(i.suffixes()))), " ")
+}
+
+// bases returns BaseRecord followed by all Bases.
+func (i *Item) bases() []WeightedRecord {
+	return weightedRecords(i.BaseName, i.BaseRecord, i.Bases)
+}
+
+// prefixes returns PrefixRecord followed by all Prefixes.
+func (i *Item) prefixes() []WeightedRecord {
+	return weightedRecords(i.PrefixName, i.PrefixRecord, i.Prefixes)
+}
+
+// suffixes returns SuffixRecord followed by all Suffixes.
+func (i *Item) suffixes() []WeightedRecord {
+	return weightedRecords(i.SuffixName, i.SuffixRecord, i.Suffixes)
+}
+
+// affixes returns all prefixes, suffixes and broken affixes.
+func (i *Item) affixes() []WeightedRecord {
+	return append(append(i.prefixes(), i.suffixes()...), i.BrokenAffixes...)
+}
+
+func weightedRecords(name, record string, records []WeightedRecord) []WeightedRecord {
+	var all []WeightedRecord
+	if record != "" {
+		all = append(all, WeightedRecord{Name: name, Record: record})
+	}
+	return append(all, records...)
+}
+
+// recordNames joins the names of all records to describe them in a table.
+func recordNames(records []WeightedRecord) string {
+	names := make([]string, 0, len(records))
+	for _, r := range records {
+		if r.Name != "" {
+			names = append(names, r.Name)
+		}
+	}
+	return strings.Join(names, "/")
+}
+
+// validateWeighted checks that the records of owner are set and that none of their weights is negative.
+// Records with only a name are fine, they are looked up by their name.
+func validateWeighted(records []WeightedRecord, owner string) error {
+	for _, r := range records {
+		if r.Record == "" && r.Name == "" {
+			return fmt.Errorf("record of %s is missing", owner)
+		}
+		if r.Weight < 0 {
+			return fmt.Errorf("weight %d of %s %s is negative", r.Weight, owner, r.description())
+		}
+	}
+	return nil
+}
+
+// description returns the record or the name if there is no record.
+func (r WeightedRecord) description() string {
+	if r.Record == "" {
+		return r.Name
+	}
+	return r.Record
+}
+
+func (r WeightedRecord) weight() int {
+	if r.Weight == 0 {
+		return 100
+	}
+	return r.Weight
+}
+
 // FromFile reads a given file path and builds an equipment struct from that.
 func FromFile(path string) (*Equipment, error) {
 	f, err := ioutil.ReadFile(path)
@@ -158,78 +526,239 @@ func FromFile(path string) (*Equipment, error) {
 			return nil, fmt.Errorf("item %s is not valid: %v", i.BaseName, err)
 		}
 	}
+	for _, m := range e.Masteries {
+		if err := validateMastery(m); err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range e.Monsters {
+		if err := m.Validate(); err != nil {
+			return nil, fmt.Errorf("monster %s is not valid: %v", m.Record, err)
+		}
+	}
+	for _, c := range e.Containers {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("container %s is not valid: %v", c.description(), err)
+		}
+	}
+	for _, a := range e.Artifacts {
+		if err := a.Validate(); err != nil {
+			return nil, fmt.Errorf("artifact %s is not valid: %v", a.Name, err)
+		}
+	}
+	for _, q := range e.Quests {
+		if err := q.Validate(); err != nil {
+			return nil, fmt.Errorf("quest %s is not valid: %v", q.Name, err)
+		}
+	}
+	for _, d := range e.Difficulties {
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("difficulty %s is not valid: %v", d.Name, err)
+		}
+	}
+	if e.DatabasePath != "" {
+		if err := e.OpenDatabase(); err != nil {
+			return nil, err
+		}
+		if err := e.resolveNames(); err != nil {
+			return nil, err
+		}
+		if err := e.verifyRecords(); err != nil {
+			return nil, err
+		}
+		if err := e.verifySlots(); err != nil {
+			return nil, err
+		}
+		if err := e.verifySets(); err != nil {
+			return nil, err
+		}
+		if err := e.verifyRequirements(); err != nil {
+			return nil, err
+		}
+	}
 	return &e, nil
 }
 
-func createItemAffixTable(path, affixName, affixRecord string) (*table, error) {
-	headers, err := createTableHeader("itemAffixTable", affixName)
+// OpenDatabase opens the game database at DatabasePath to read records from it.
+func (e *Equipment) OpenDatabase() error {
+	db, err := arz.Open(e.DatabasePath)
+	if err != nil {
+		return err
+	}
+	e.db = db
+	return nil
+}
+
+// warnf reports a problem with the equipment that does not stop it from working.
+// Strict equipment treats warnings as errors.
+func (e *Equipment) warnf(format string, args ...interface{}) error {
+	if e.Strict {
+		return fmt.Errorf(format, args...)
+	}
+	log.Printf("warning: "+format, args...)
+	return nil
+}
+
+// verifyRecords checks that every record referenced by the items of all difficulties and the artifacts exists in the game database
+// and that the affixes can roll on their base items.
+func (e *Equipment) verifyRecords() error {
+	for _, i := range e.allItems() {
+		var records []string
+		for _, r := range append(i.bases(), i.affixes()...) {
+			records = append(records, r.Record)
+		}
+		records = append(records, i.relicRecords()...)
+		for _, record := range records {
+			if record != "" && !e.db.Has(record) {
+				return fmt.Errorf("item %s references %s which is not in the database", i.BaseName, record)
+			}
+		}
+		if err := e.verifyAffixes(i); err != nil {
+			return fmt.Errorf("item %s is not valid: %v", i.BaseName, err)
+		}
+	}
+	for _, a := range e.Artifacts {
+		for _, record := range a.records() {
+			if !e.db.Has(record) {
+				return fmt.Errorf("artifact %s references %s which is not in the database", a.Name, record)
+			}
+		}
+	}
+	return nil
+}
+
+func createItemAffixTable(path string, affixes []WeightedRecord) (*table, error) {
+	record, err := createTableHeader("itemAffixTable", recordNames(affixes))
 	if err != nil {
 		// this literally cannot happen right now until the createTableHeader function changes
 		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
 	}
-	body := []byte(fmt.Sprintf("randomizerName1,%s,\nrandomizerWeight1,100,\n", affixRecord))
+	for n, affix := range affixes {
+		record.Set(fmt.Sprintf("randomizerName%d", n+1), affix.Record)
+		record.Set(fmt.Sprintf("randomizerWeight%d", n+1), strconv.Itoa(affix.weight()))
+	}
 	t := table{
-		Path:    path,
-		Headers: headers,
-		Body:    body,
+		Path:   path,
+		Record: record,
 	}
 	return &t, nil
 }
 
-func createItemTable(path, lootPath, prefixPath, suffixPath, description string) (*table, error) {
-	headers, err := createTableHeader("itemTable", description)
+func createItemTable(path string, item Item, prefixPath, suffixPath, brokenPath string) (*table, error) {
+	tableType := "itemTable"
+	if item.TableType == DynWeight {
+		tableType = "dynItemTable"
+	}
+	record, err := createTableHeader(tableType, item.description())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
 	}
-	// baseConfig := []byte("bothPrefixSuffix,100\nbrokenOnly,0,\nbrokenRandomizerChance,0.000000,\nbrokenRandomizerName1,,\nbrokenRandomizerWeight1,,")
-	baseConfig := []byte("bothPrefixSuffix,100,\n")
-	lootConfig := []byte(fmt.Sprintf("lootName1,%s,\nlootWeight1,100,\n", lootPath))
-	prefixConfig := []byte(fmt.Sprintf("prefixRandomizerChance,100.000000,\nprefixRandomizerName1,%s,\nprefixRandomizerWeight1,100,\n", prefixPath))
-	suffixConfig := []byte(fmt.Sprintf("suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,%s,\nsuffixRandomizerWeight1,100,\n", suffixPath))
-	configs := [][]byte{baseConfig, lootConfig, prefixConfig, suffixConfig}
-	var body []byte
-	for _, c := range configs {
-		body = append(body, c...)
+	record.Set("bothPrefixSuffix", strconv.FormatFloat(percentage(item.BothPrefixSuffix), 'f', -1, 64))
+	if item.broken() {
+		record.Set("brokenOnly", boolValue(item.BrokenOnly))
+		record.Set("brokenRandomizerChance", fmt.Sprintf("%f", item.BrokenChance))
+		record.Set("brokenRandomizerName1", brokenPath)
+		record.Set("brokenRandomizerWeight1", "100")
+	}
+	if item.TableType == DynWeight {
+		var names []string
+		for _, base := range item.bases() {
+			names = append(names, base.Record)
+		}
+		record.SetValues("itemNames", names...)
+		if item.MinLevel > 0 {
+			record.Set("minItemLevelEquation", strconv.Itoa(item.MinLevel))
+		}
+		if item.MaxLevel > 0 {
+			record.Set("maxItemLevelEquation", strconv.Itoa(item.MaxLevel))
+		}
+	} else {
+		for n, base := range item.bases() {
+			record.Set(fmt.Sprintf("lootName%d", n+1), base.Record)
+			record.Set(fmt.Sprintf("lootWeight%d", n+1), strconv.Itoa(base.weight()))
+		}
 	}
+	record.Set("prefixRandomizerChance", fmt.Sprintf("%f", percentage(item.PrefixChance)))
+	record.Set("prefixRandomizerName1", prefixPath)
+	record.Set("prefixRandomizerWeight1", "100")
+	record.Set("suffixRandomizerChance", fmt.Sprintf("%f", percentage(item.SuffixChance)))
+	record.Set("suffixRandomizerName1", suffixPath)
+	record.Set("suffixRandomizerWeight1", "100")
 	t := table{
-		Path:    path,
-		Headers: headers,
-		Body:    body,
+		Path:   path,
+		Record: record,
 	}
 	return &t, nil
 }
 
-func createMerchantTable(path, itemPath, description string) (*table, error) {
-	headers, err := createTableHeader("merchantTable", description)
+func boolValue(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func createMerchantTable(path, description string, itemTables []WeightedRecord) (*table, error) {
+	record, err := createTableHeader("merchantTable", description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
 	}
-	body := []byte(fmt.Sprintf("lootName1,%s,\nlootWeight1,100,\n", itemPath))
+	for n, itemTable := range itemTables {
+		record.Set(fmt.Sprintf("lootName%d", n+1), itemTable.Record)
+		record.Set(fmt.Sprintf("lootWeight%d", n+1), strconv.Itoa(itemTable.weight()))
+	}
 	t := table{
-		Path:    path,
-		Headers: headers,
-		Body:    body,
+		Path:   path,
+		Record: record,
 	}
 	return &t, nil
 }
 
-func createTableHeader(tableType, tableDescription string) ([]byte, error) {
+// createLootTable creates a table that drops one of the records by their weight without any affixes.
+func createLootTable(path string, records []WeightedRecord) (*table, error) {
+	record, err := createTableHeader("itemTable", recordNames(records))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
+	}
+	for n, r := range records {
+		record.Set(fmt.Sprintf("lootName%d", n+1), r.Record)
+		record.Set(fmt.Sprintf("lootWeight%d", n+1), strconv.Itoa(r.weight()))
+	}
+	t := table{
+		Path:   path,
+		Record: record,
+	}
+	return &t, nil
+}
+
+func createTableHeader(tableType, tableDescription string) (*dbr.Record, error) {
 	var template string
 	var class string
 	switch tableType {
-	case "merchantTable":
+	case "merchantTable", "rewardTable":
 		template = merchantTableTemplate
 		class = merchantTableClass
 	case "itemTable":
 		template = itemTableTemplate
 		class = itemTableClass
+	case "dynItemTable":
+		template = dynItemTableTemplate
+		class = dynItemTableClass
 	case "itemAffixTable":
 		template = affixTableTemplate
 		class = affixTableClass
+	case "containerTable":
+		template = containerTemplate
+		class = containerClass
 	default:
 		return nil, fmt.Errorf("wrong tableType: %s", tableType)
 	}
-	return []byte(fmt.Sprintf("templateName,%s,\nActorName,,\nClass,%s,\nFileDescription,%s,\n", template, class, tableDescription)), nil
+	record := dbr.New()
+	record.Set("templateName", template)
+	record.Set("ActorName", "")
+	record.Set("Class", class)
+	record.Set("FileDescription", tableDescription)
+	return record, nil
 }
 
 func (e *Equipment) createItems() error {
@@ -238,19 +767,68 @@ func (e *Equipment) createItems() error {
 			return err
 		}
 	}
+	if err := e.createArtifacts(); err != nil {
+		return err
+	}
+	if len(e.Items) == 0 && len(e.Artifacts) == 0 {
+		return nil
+	}
+	return e.createEquipmentMerchant()
+}
+
+// createEquipmentMerchant writes the merchant table that sells the items of all slots and the artifact formulae.
+func (e *Equipment) createEquipmentMerchant() error {
+	var itemTables []WeightedRecord
+	for _, item := range e.Items {
+		itemTables = append(itemTables, WeightedRecord{
+			Name:   item.description(),
+			Record: e.slotTablePath(item, "itemTable.dbr"),
+			Weight: item.MerchantWeight,
+		})
+		if len(item.Relics) > 0 {
+			itemTables = append(itemTables, WeightedRecord{
+				Name:   recordNames(item.relics()),
+				Record: e.slotTablePath(item, "relicTable.dbr"),
+				Weight: item.MerchantWeight,
+			})
+		}
+	}
+	if len(e.Artifacts) > 0 {
+		itemTables = append(itemTables, WeightedRecord{
+			Name:   e.artifactNames(),
+			Record: e.formulaTablePath(),
+		})
+	}
+	merchantTable, err := createMerchantTable(e.merchantTablePath(), e.Name, itemTables)
+	if err != nil {
+		return fmt.Errorf("failed to initialise %s: %v", merchantTable.Path, err)
+	}
+	if err := merchantTable.write(e.FolderPath); err != nil {
+		return fmt.Errorf("failed to write table to %s: %v", merchantTable.Path, err)
+	}
 	return nil
 }
 
+// merchantTablePath returns the path of the merchant table of the whole equipment relative to FolderPath.
+func (e *Equipment) merchantTablePath() string {
+	return filepath.Join(e.TablePath, "merchantTable.dbr")
+}
+
+// slotTablePath returns the path of a table that belongs to the slot of an item relative to FolderPath.
+func (e *Equipment) slotTablePath(item Item, name string) string {
+	return filepath.Join(e.TablePath, item.SlotIdentifier.String(), name)
+}
+
 func (e *Equipment) createItem(item Item) error {
 	if err := item.Validate(); err != nil {
 		return fmt.Errorf("item is invalid: %v", err)
 	}
-	baseTablePath := filepath.Join(e.TablePath, item.SlotIdentifier)
+	baseTablePath := filepath.Join(e.TablePath, item.SlotIdentifier.String())
 	writePath := filepath.Join(e.FolderPath, baseTablePath)
-	if err := os.MkdirAll(writePath, 0644); err != nil {
+	if err := os.MkdirAll(writePath, 0755); err != nil {
 		return fmt.Errorf("failed to create %s: %v", baseTablePath, err)
 	}
-	prefixTable, err := createItemAffixTable(filepath.Join(baseTablePath, "itemPrefixTable.dbr"), item.PrefixName, item.PrefixRecord)
+	prefixTable, err := createItemAffixTable(filepath.Join(baseTablePath, "itemPrefixTable.dbr"), item.prefixes())
 	if err != nil {
 		return fmt.Errorf("failed to initialise %s: %v", prefixTable.Path, err)
 	}
@@ -258,7 +836,7 @@ func (e *Equipment) createItem(item Item) error {
 		return fmt.Errorf("failed to write table to %s: %v", prefixTable.Path, err)
 	}
 
-	suffixTable, err := createItemAffixTable(filepath.Join(baseTablePath, "itemSuffixTable.dbr"), item.SuffixName, item.SuffixRecord)
+	suffixTable, err := createItemAffixTable(filepath.Join(baseTablePath, "itemSuffixTable.dbr"), item.suffixes())
 	if err != nil {
 		return fmt.Errorf("failed to initialise %s: %v", suffixTable.Path, err)
 	}
@@ -266,12 +844,28 @@ func (e *Equipment) createItem(item Item) error {
 		return fmt.Errorf("failed to write table to %s: %v", suffixTable.Path, err)
 	}
 
+	var brokenTablePath string
+	if item.broken() {
+		brokenTable, err := createItemAffixTable(filepath.Join(baseTablePath, "itemBrokenTable.dbr"), item.BrokenAffixes)
+		if err != nil {
+			return fmt.Errorf("failed to initialise %s: %v", brokenTable.Path, err)
+		}
+		if err := brokenTable.write(e.FolderPath); err != nil {
+			return fmt.Errorf("failed to write table to %s: %v", brokenTable.Path, err)
+		}
+		brokenTablePath = brokenTable.Path
+	}
+
+	named, err := e.nameBases(item)
+	if err != nil {
+		return err
+	}
 	itemTable, err := createItemTable(
 		filepath.Join(baseTablePath, "itemTable.dbr"),
-		item.BaseRecord,
+		named,
 		prefixTable.Path,
 		suffixTable.Path,
-		fmt.Sprintf("%s %s %s", item.PrefixName, item.BaseName, item.SuffixName),
+		brokenTablePath,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to initialise %s: %v", itemTable.Path, err)
@@ -280,7 +874,19 @@ func (e *Equipment) createItem(item Item) error {
 		return fmt.Errorf("failed to write table to %s: %v", itemTable.Path, err)
 	}
 
-	merchantTable, err := createMerchantTable(filepath.Join(baseTablePath, "merchantTable.dbr"), itemTable.Path, item.BaseName)
+	relicTablePath, err := e.createRelics(item)
+	if err != nil {
+		return err
+	}
+	merchantTables := []WeightedRecord{{Record: itemTable.Path}}
+	if relicTablePath != "" {
+		merchantTables = append(merchantTables, WeightedRecord{Record: relicTablePath})
+	}
+	merchantTable, err := createMerchantTable(
+		filepath.Join(baseTablePath, "merchantTable.dbr"),
+		item.BaseName,
+		merchantTables,
+	)
 	if err != nil {
 		return fmt.Errorf("failed to initialise %s: %v", merchantTable.Path, err)
 	}
@@ -292,7 +898,7 @@ func (e *Equipment) createItem(item Item) error {
 
 func (t *table) write(folderPath string) error {
 	writePath := filepath.Join(folderPath, t.Path)
-	err := ioutil.WriteFile(writePath, append(t.Headers, t.Body...), 0644)
+	err := ioutil.WriteFile(writePath, t.Record.Bytes(), 0644)
 	if err != nil {
 		return fmt.Errorf("failed writing table file %s: %v", t.Path, err)
 	}
@@ -1,177 +1,21 @@
 package equipment
 
 import (
-	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/Deichindianer/tq-item-setup/arc"
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/Deichindianer/tq-item-setup/tags"
 	"github.com/go-test/deep"
 )
 
-func TestSlotFromString(t *testing.T) {
-	testData := []struct {
-		Name string
-		In   string
-		Out  Slot
-		OK   bool
-	}{
-		{
-			Name: "ValidSlot",
-			In:   "Amulet",
-			Out:  Amulet,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "Arm",
-			Out:  Arm,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "Head",
-			Out:  Head,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "Leg",
-			Out:  Leg,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "RingLeft",
-			Out:  RingLeft,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "RingRight",
-			Out:  RingRight,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "Torso",
-			Out:  Torso,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "WeaponLeft",
-			Out:  WeaponLeft,
-			OK:   true,
-		},
-		{
-			Name: "ValidSlot",
-			In:   "WeaponRight",
-			Out:  WeaponRight,
-			OK:   true,
-		},
-		{
-			Name: "InvalidSlot",
-			In:   "FooBar",
-			Out:  UnknownSlot,
-			OK:   false,
-		},
-	}
-	for _, td := range testData {
-		t.Run(td.Name, func(t *testing.T) {
-			s, err := SlotFromString(td.In)
-			if err != nil && td.OK {
-				t.Errorf("unexpected error: %v", err)
-			}
-			if err == nil && !td.OK {
-				t.Error("expected error but got nil")
-			}
-			if s != td.Out {
-				t.Errorf("expected %s got %s instead", td.Out, s)
-			}
-		})
-	}
-}
-
-func TestString(t *testing.T) {
-	testData := []struct {
-		Name string
-		In   Slot
-		Out  string
-		OK   bool
-	}{
-		{
-			Name: "ValidSlotAmulet",
-			In:   Amulet,
-			Out:  "Amulet",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotArm",
-			In:   Arm,
-			Out:  "Arm",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotHead",
-			In:   Head,
-			Out:  "Head",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotLeg",
-			In:   Leg,
-			Out:  "Leg",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotRingLeft",
-			In:   RingLeft,
-			Out:  "RingLeft",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotRingRight",
-			In:   RingRight,
-			Out:  "RingRight",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotTorso",
-			In:   Torso,
-			Out:  "Torso",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotWeaponLeft",
-			In:   WeaponLeft,
-			Out:  "WeaponLeft",
-			OK:   true,
-		},
-		{
-			Name: "ValidSlotWeaponRight",
-			In:   WeaponRight,
-			Out:  "WeaponRight",
-			OK:   true,
-		},
-		{
-			Name: "InvalidSlot",
-			In:   UnknownSlot,
-			Out:  "",
-			OK:   true,
-		},
-	}
-	for _, td := range testData {
-		t.Run(td.Name, func(t *testing.T) {
-			s := td.In.String()
-			if s != td.Out {
-				t.Errorf("expected %s got %s instead", td.Out, s)
-			}
-		})
-	}
-}
-
 func TestFromFile(t *testing.T) {
+	half := 50.0
+	zero := 0.0
 	testData := []struct {
 		Name string
 		In   string
@@ -187,7 +31,7 @@ func TestFromFile(t *testing.T) {
 				TablePath:  `tmp\test_equip`,
 				Items: []Item{
 					{
-						SlotIdentifier: "Amulet",
+						SlotIdentifier: Amulet,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -208,7 +52,7 @@ func TestFromFile(t *testing.T) {
 				TablePath:  `tmp\test_equip`,
 				Items: []Item{
 					{
-						SlotIdentifier: "Amulet",
+						SlotIdentifier: Amulet,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -217,7 +61,7 @@ func TestFromFile(t *testing.T) {
 						SuffixRecord:   "Test/SuffixRecord/record.dbr",
 					},
 					{
-						SlotIdentifier: "Head",
+						SlotIdentifier: Head,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -229,6 +73,72 @@ func TestFromFile(t *testing.T) {
 			},
 			OK: true,
 		},
+		{
+			Name: "ValidEquipmentWeightedAffixes",
+			In:   "../testData/validEquipmentWeightedAffixes.yml",
+			Out: &Equipment{
+				Name:       "TestEquipment",
+				FolderPath: `C:\TMP`,
+				TablePath:  `tmp\test_equip`,
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "Test/BaseRecord/record.dbr",
+						Prefixes: []WeightedRecord{
+							{Name: "TestPrefixName", Record: "Test/PrefixRecord/record.dbr", Weight: 100},
+						},
+						Suffixes: []WeightedRecord{
+							{Name: "of Fortitude", Record: "Test/SuffixRecord/fortitude.dbr", Weight: 70},
+							{Name: "of the Bear", Record: "Test/SuffixRecord/bear.dbr", Weight: 30},
+						},
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "ValidEquipmentMultipleBases",
+			In:   "../testData/validEquipmentMultipleBases.yml",
+			Out: &Equipment{
+				Name:       "TestEquipment",
+				FolderPath: `C:\TMP`,
+				TablePath:  `tmp\test_equip`,
+				Items: []Item{
+					{
+						SlotIdentifier: Head,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "Test/BaseRecord/record.dbr",
+						Bases: []WeightedRecord{
+							{Name: "TestHelm", Record: "Test/BaseRecord/helm.dbr", Weight: 50},
+							{Name: "TestCap", Record: "Test/BaseRecord/cap.dbr"},
+						},
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "ValidEquipmentContainers",
+			In:   "../testData/validEquipmentContainers.yml",
+			Out: &Equipment{
+				Name:       "TestEquipment",
+				FolderPath: `C:\TMP`,
+				TablePath:  `tmp\test_equip`,
+				Containers: []Container{
+					{Name: "TestChest", Chance: &half, MinDrops: 1, MaxDrops: 2, Slots: []Slot{Amulet}},
+					{Record: `records\item\containers\testchest.dbr`},
+				},
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "Test/BaseRecord/record.dbr",
+					},
+				},
+			},
+			OK: true,
+		},
 		{
 			Name: "ValidEquipmentNoItems",
 			In:   "../testData/validEquipmentNoItems.yml",
@@ -240,6 +150,286 @@ func TestFromFile(t *testing.T) {
 			},
 			OK: true,
 		},
+		{
+			Name: "ValidEquipmentDatabase",
+			In:   "../testData/validEquipmentDatabase.yml",
+			Out: &Equipment{
+				Name:         "TestEquipment",
+				FolderPath:   `C:\TMP`,
+				TablePath:    `tmp\test_equip`,
+				DatabasePath: "../testData/database.arz",
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "records/item/equipmentamulet/testamulet.dbr",
+						PrefixName:     "TestPrefixName",
+						PrefixRecord:   `records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+						SuffixName:     "TestSuffixName",
+						SuffixRecord:   "records/item/lootmagicalaffixes/suffix/default/testsuffix.dbr",
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "ValidEquipmentLabels",
+			In:   "../testData/validEquipmentLabels.yml",
+			Out: &Equipment{
+				Name:         "TestEquipment",
+				FolderPath:   `C:\TMP`,
+				TablePath:    `tmp\test_equip`,
+				DatabasePath: "../testData/database.arz",
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "records/item/equipmentamulet/testamulet.dbr",
+						PrefixName:     "TestPrefixName",
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "ValidEquipmentArtifacts",
+			In:   "../testData/validEquipmentArtifacts.yml",
+			Out: &Equipment{
+				Name:         "TestEquipment",
+				FolderPath:   `C:\TMP`,
+				TablePath:    `tmp\test_equip`,
+				DatabasePath: "../testData/database.arz",
+				Artifacts: []ArtifactLoot{
+					{
+						Name:    "TestArtifact",
+						Formula: "records/item/artifacts/arcaneformulae/testformula.dbr",
+						Weight:  10,
+						Bonuses: []WeightedRecord{
+							{Record: "records/item/lootmagicalaffixes/completionbonus/testrelicbonus.dbr"},
+						},
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "InvalidEquipmentArtifact",
+			In:   "../testData/invalidEquipmentArtifact.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentArtifactWeight",
+			In:   "../testData/invalidEquipmentArtifactWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "ValidEquipmentSet",
+			In:   "../testData/validEquipmentSet.yml",
+			Out: &Equipment{
+				Name:         "TestEquipment",
+				FolderPath:   `C:\TMP`,
+				TablePath:    `tmp\test_equip`,
+				DatabasePath: "../testData/database.arz",
+				CompleteSets: true,
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestSetAmulet",
+						BaseRecord:     "records/item/equipmentamulet/testsetamulet.dbr",
+					},
+					{
+						SlotIdentifier: RingLeft,
+						BaseRecord:     `records\item\equipmentring\testsetring.dbr`,
+						PrefixChance:   &zero,
+						SuffixChance:   &zero,
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "InvalidEquipmentSet",
+			In:   "../testData/invalidEquipmentSet.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "ValidEquipmentDifficulties",
+			In:   "../testData/validEquipmentDifficulties.yml",
+			Out: &Equipment{
+				Name:       "TestEquipment",
+				FolderPath: `C:\TMP`,
+				TablePath:  `tmp\test_equip`,
+				Difficulties: []Difficulty{
+					{
+						Name:      "Epic",
+						Merchants: []string{"records/creature/npc/merchant/epicmerchant.dbr"},
+						Items: []Item{
+							{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/epic.dbr"},
+						},
+					},
+				},
+				Items: []Item{
+					{SlotIdentifier: Amulet, BaseName: "TestBaseName", BaseRecord: "Test/BaseRecord/record.dbr"},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "InvalidEquipmentDifficulty",
+			In:   "../testData/invalidEquipmentDifficulty.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentSlotClass",
+			In:   "../testData/invalidEquipmentSlotClass.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "ValidEquipmentNames",
+			In:   "../testData/validEquipmentNames.yml",
+			Out: &Equipment{
+				Name:         "TestEquipment",
+				FolderPath:   `C:\TMP`,
+				TablePath:    `tmp\test_equip`,
+				DatabasePath: "../testData/database.arz",
+				TextPath:     "../testData/Text_EN.arc",
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "Test Amulet",
+						BaseRecord:     `records\item\equipmentamulet\testamulet.dbr`,
+						SuffixName:     "of the Bear",
+						SuffixRecord:   `records\item\lootmagicalaffixes\suffix\default\testsuffix.dbr`,
+					},
+				},
+			},
+			OK: true,
+		},
+		{
+			Name: "InvalidEquipmentAmbiguousName",
+			In:   "../testData/invalidEquipmentAmbiguousName.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentMissingRecord",
+			In:   "../testData/invalidEquipmentMissingRecord.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentAffixClass",
+			In:   "../testData/invalidEquipmentAffixClass.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentMissingBrokenAffix",
+			In:   "../testData/invalidEquipmentMissingBrokenAffix.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentBrokenAffixClass",
+			In:   "../testData/invalidEquipmentBrokenAffixClass.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentBrokenAffixes",
+			In:   "../testData/invalidEquipmentBrokenAffixes.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentMissingSlot",
+			In:   "../testData/invalidEquipmentMissingSlot.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentMastery",
+			In:   "../testData/invalidEquipmentMastery.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentChance",
+			In:   "../testData/invalidEquipmentChance.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentLevelRange",
+			In:   "../testData/invalidEquipmentLevelRange.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentFixedWeightLevelRange",
+			In:   "../testData/invalidEquipmentFixedWeightLevelRange.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentContainer",
+			In:   "../testData/invalidEquipmentContainer.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentRelic",
+			In:   "../testData/invalidEquipmentRelic.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentRelicWeight",
+			In:   "../testData/invalidEquipmentRelicWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentRelicBonusWeight",
+			In:   "../testData/invalidEquipmentRelicBonusWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentQuest",
+			In:   "../testData/invalidEquipmentQuest.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentMerchantWeight",
+			In:   "../testData/invalidEquipmentMerchantWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentBaseWeight",
+			In:   "../testData/invalidEquipmentBaseWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentAffixWeight",
+			In:   "../testData/invalidEquipmentAffixWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
+		{
+			Name: "InvalidEquipmentBrokenAffixWeight",
+			In:   "../testData/invalidEquipmentBrokenAffixWeight.yml",
+			Out:  nil,
+			OK:   false,
+		},
 		{
 			Name: "InvalidEquipment",
 			In:   "../testData/invalidEquipment.yml",
@@ -322,49 +512,62 @@ func TestNew(t *testing.T) {
 
 func TestCreateAffixTable(t *testing.T) {
 	testData := []struct {
-		Name          string
-		InPath        string
-		InAffixName   string
-		InAffixRecord string
-		Out           *table
-		OK            bool
+		Name      string
+		InPath    string
+		InAffixes []WeightedRecord
+		OutPath   string
+		Out       string
+		OK        bool
 	}{
 		{
-			Name:          "ValidTableUnixPath",
-			InPath:        "test/Amulet/ItemPrefixTable.dbr",
-			InAffixName:   "TestAffix",
-			InAffixRecord: "records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr",
-			Out: &table{
-				Path:    "test/Amulet/ItemPrefixTable.dbr",
-				Headers: []byte("templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,TestAffix,\n"),
-				Body:    []byte("randomizerName1,records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr,\nrandomizerWeight1,100,\n"),
+			Name:   "ValidTableUnixPath",
+			InPath: "test/Amulet/ItemPrefixTable.dbr",
+			InAffixes: []WeightedRecord{
+				{Name: "TestAffix", Record: "records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr"},
 			},
+			OutPath: "test/Amulet/ItemPrefixTable.dbr",
+			Out: "templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,TestAffix,\n" +
+				"randomizerName1,records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr,\nrandomizerWeight1,100,\n",
 			OK: true,
 		},
 		{
-			Name:          "ValidTableWindowsPath",
-			InPath:        "test\\Amulet\\ItemPrefixTable.dbr",
-			InAffixName:   "TestAffix",
-			InAffixRecord: "records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr",
-			Out: &table{
-				Path:    "test\\Amulet\\ItemPrefixTable.dbr",
-				Headers: []byte("templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,TestAffix,\n"),
-				Body:    []byte("randomizerName1,records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr,\nrandomizerWeight1,100,\n"),
+			Name:   "ValidTableWindowsPath",
+			InPath: "test\\Amulet\\ItemPrefixTable.dbr",
+			InAffixes: []WeightedRecord{
+				{Name: "TestAffix", Record: "records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr"},
 			},
+			OutPath: "test\\Amulet\\ItemPrefixTable.dbr",
+			Out: "templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,TestAffix,\n" +
+				"randomizerName1,records/item/LootMagicalAffixes/Prefix/Default/TestAffix.dbr,\nrandomizerWeight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:   "ValidTableWeightedAffixes",
+			InPath: "test/Amulet/ItemSuffixTable.dbr",
+			InAffixes: []WeightedRecord{
+				{Name: "of Fortitude", Record: "records/item/lootmagicalaffixes/suffix/default/fortitude.dbr", Weight: 70},
+				{Name: "of the Bear", Record: "records/item/lootmagicalaffixes/suffix/default/bear.dbr", Weight: 30},
+			},
+			OutPath: "test/Amulet/ItemSuffixTable.dbr",
+			Out: "templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,of Fortitude/of the Bear,\n" +
+				"randomizerName1,records/item/lootmagicalaffixes/suffix/default/fortitude.dbr,\nrandomizerWeight1,70,\n" +
+				"randomizerName2,records/item/lootmagicalaffixes/suffix/default/bear.dbr,\nrandomizerWeight2,30,\n",
 			OK: true,
 		},
 	}
 	for _, td := range testData {
 		t.Run(td.Name, func(t *testing.T) {
-			table, err := createItemAffixTable(td.InPath, td.InAffixName, td.InAffixRecord)
+			table, err := createItemAffixTable(td.InPath, td.InAffixes)
 			if err != nil && td.OK {
 				t.Errorf("unexpected error: %v", err)
 			}
 			if err == nil && !td.OK {
 				t.Error("expected error but got nil")
 			}
-			diff := deep.Equal(*table, *td.Out)
-			if diff != nil {
+			if table.Path != td.OutPath {
+				t.Errorf("expected path %s got %s instead", td.OutPath, table.Path)
+			}
+			if diff := deep.Equal(string(table.Record.Bytes()), td.Out); diff != nil {
 				t.Errorf("result differs from expected table: %+v", diff)
 			}
 		})
@@ -372,62 +575,125 @@ func TestCreateAffixTable(t *testing.T) {
 }
 
 func TestCreateItemTable(t *testing.T) {
+	zero := 0.0
 	testData := []struct {
-		Name          string
-		InPath        string
-		InLootPath    string
-		InPrefixPath  string
-		InSuffixPath  string
-		InDescription string
-		Out           *table
-		OK            bool
+		Name         string
+		InPath       string
+		InItem       Item
+		InPrefixPath string
+		InSuffixPath string
+		InBrokenPath string
+		OutPath      string
+		Out          string
+		OK           bool
 	}{
 		{
-			Name:          "ValidTableUnixPath",
-			InPath:        "test/Amulet/ItemTable.dbr",
-			InLootPath:    "records/item/equipmenthelm/helm.dbr",
-			InPrefixPath:  "test/Amulet/ItemPrefixTable.dbr",
-			InSuffixPath:  "test/Amulet/ItemSuffixTable.dbr",
-			InDescription: "TestItemTable",
-			Out: &table{
-				Path:    "test/Amulet/ItemTable.dbr",
-				Headers: []byte("templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestItemTable,\n"),
-				Body: []byte("bothPrefixSuffix,100,\n" +
-					"lootName1,records/item/equipmenthelm/helm.dbr,\nlootWeight1,100,\n" +
-					"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test/Amulet/ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
-					"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test/Amulet/ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n"),
+			Name:         "ValidTableUnixPath",
+			InPath:       "test/Amulet/ItemTable.dbr",
+			InItem:       Item{BaseName: "TestItemTable", BaseRecord: "records/item/equipmenthelm/helm.dbr"},
+			InPrefixPath: "test/Amulet/ItemPrefixTable.dbr",
+			InSuffixPath: "test/Amulet/ItemSuffixTable.dbr",
+			OutPath:      "test/Amulet/ItemTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestItemTable,\n" +
+				"bothPrefixSuffix,100,\n" +
+				"lootName1,records/item/equipmenthelm/helm.dbr,\nlootWeight1,100,\n" +
+				"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test/Amulet/ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
+				"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test/Amulet/ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:         "ValidTableWindowsPath",
+			InPath:       "test\\Amulet\\ItemTable.dbr",
+			InItem:       Item{BaseName: "TestItemTable", BaseRecord: "records\\item\\equipmenthelm\\helm.dbr"},
+			InPrefixPath: "test\\Amulet\\ItemPrefixTable.dbr",
+			InSuffixPath: "test\\Amulet\\ItemSuffixTable.dbr",
+			OutPath:      "test\\Amulet\\ItemTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestItemTable,\n" +
+				"bothPrefixSuffix,100,\n" +
+				"lootName1,records\\item\\equipmenthelm\\helm.dbr,\nlootWeight1,100,\n" +
+				"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test\\Amulet\\ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
+				"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test\\Amulet\\ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:   "ValidTableMultipleBases",
+			InPath: "test/Head/ItemTable.dbr",
+			InItem: Item{
+				Bases: []WeightedRecord{
+					{Name: "Helm", Record: "records/item/equipmenthelm/helm.dbr", Weight: 60},
+					{Name: "Cap", Record: "records/item/equipmenthelm/cap.dbr", Weight: 40},
+				},
 			},
+			InPrefixPath: "test/Head/ItemPrefixTable.dbr",
+			InSuffixPath: "test/Head/ItemSuffixTable.dbr",
+			OutPath:      "test/Head/ItemTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,Helm/Cap,\n" +
+				"bothPrefixSuffix,100,\n" +
+				"lootName1,records/item/equipmenthelm/helm.dbr,\nlootWeight1,60,\n" +
+				"lootName2,records/item/equipmenthelm/cap.dbr,\nlootWeight2,40,\n" +
+				"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test/Head/ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
+				"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test/Head/ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n",
 			OK: true,
 		},
 		{
-			Name:          "ValidTableWindowsPath",
-			InPath:        "test\\Amulet\\ItemTable.dbr",
-			InLootPath:    "records\\item\\equipmenthelm\\helm.dbr",
-			InPrefixPath:  "test\\Amulet\\ItemPrefixTable.dbr",
-			InSuffixPath:  "test\\Amulet\\ItemSuffixTable.dbr",
-			InDescription: "TestItemTable",
-			Out: &table{
-				Path:    "test\\Amulet\\ItemTable.dbr",
-				Headers: []byte("templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestItemTable,\n"),
-				Body: []byte("bothPrefixSuffix,100,\n" +
-					"lootName1,records\\item\\equipmenthelm\\helm.dbr,\nlootWeight1,100,\n" +
-					"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test\\Amulet\\ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
-					"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test\\Amulet\\ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n"),
+			Name:   "ValidTableDynWeight",
+			InPath: "test/Head/ItemTable.dbr",
+			InItem: Item{
+				Bases: []WeightedRecord{
+					{Name: "Helm", Record: "records/item/equipmenthelm/helm.dbr", Weight: 60},
+					{Name: "Cap", Record: "records/item/equipmenthelm/cap.dbr"},
+				},
+				TableType: DynWeight,
+				MinLevel:  20,
+				MaxLevel:  45,
 			},
+			InPrefixPath: "test/Head/ItemPrefixTable.dbr",
+			InSuffixPath: "test/Head/ItemSuffixTable.dbr",
+			OutPath:      "test/Head/ItemTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_DynWeight.tpl,\nActorName,,\nClass,LootItemTable_DynWeight,\nFileDescription,Helm/Cap,\n" +
+				"bothPrefixSuffix,100,\n" +
+				"itemNames,records/item/equipmenthelm/helm.dbr;records/item/equipmenthelm/cap.dbr,\n" +
+				"minItemLevelEquation,20,\nmaxItemLevelEquation,45,\n" +
+				"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test/Head/ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
+				"suffixRandomizerChance,100.000000,\nsuffixRandomizerName1,test/Head/ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:   "ValidTablePrefixOnlyBroken",
+			InPath: "test/Amulet/ItemTable.dbr",
+			InItem: Item{
+				BaseName:         "TestItemTable",
+				BaseRecord:       "records/item/equipmenthelm/helm.dbr",
+				BothPrefixSuffix: &zero,
+				SuffixChance:     &zero,
+				BrokenChance:     12.5,
+			},
+			InPrefixPath: "test/Amulet/ItemPrefixTable.dbr",
+			InSuffixPath: "test/Amulet/ItemSuffixTable.dbr",
+			InBrokenPath: "test/Amulet/ItemBrokenTable.dbr",
+			OutPath:      "test/Amulet/ItemTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestItemTable,\n" +
+				"bothPrefixSuffix,0,\n" +
+				"brokenOnly,0,\nbrokenRandomizerChance,12.500000,\nbrokenRandomizerName1,test/Amulet/ItemBrokenTable.dbr,\nbrokenRandomizerWeight1,100,\n" +
+				"lootName1,records/item/equipmenthelm/helm.dbr,\nlootWeight1,100,\n" +
+				"prefixRandomizerChance,100.000000,\nprefixRandomizerName1,test/Amulet/ItemPrefixTable.dbr,\nprefixRandomizerWeight1,100,\n" +
+				"suffixRandomizerChance,0.000000,\nsuffixRandomizerName1,test/Amulet/ItemSuffixTable.dbr,\nsuffixRandomizerWeight1,100,\n",
 			OK: true,
 		},
 	}
 	for _, td := range testData {
 		t.Run(td.Name, func(t *testing.T) {
-			table, err := createItemTable(td.InPath, td.InLootPath, td.InPrefixPath, td.InSuffixPath, td.InDescription)
+			table, err := createItemTable(td.InPath, td.InItem, td.InPrefixPath, td.InSuffixPath, td.InBrokenPath)
 			if err != nil && td.OK {
 				t.Errorf("unexpected error: %v", err)
 			}
 			if err == nil && !td.OK {
 				t.Error("expected error but got nil")
 			}
-			diff := deep.Equal(*table, *td.Out)
-			if diff != nil {
+			if table.Path != td.OutPath {
+				t.Errorf("expected path %s got %s instead", td.OutPath, table.Path)
+			}
+			if diff := deep.Equal(string(table.Record.Bytes()), td.Out); diff != nil {
 				t.Errorf("result differs from expected table: %+v", diff)
 			}
 		})
@@ -438,42 +704,75 @@ func TestCreateMerchantTable(t *testing.T) {
 	testData := []struct {
 		Name          string
 		InPath        string
-		InItemPath    string
 		InDescription string
-		Out           *table
+		InItemTables  []WeightedRecord
+		OutPath       string
+		Out           string
 		OK            bool
 	}{
 		{
 			Name:          "ValidTableUnixPath",
 			InPath:        "test/Amulet/MerchantTable.dbr",
-			InItemPath:    "test/Amulet/ItemTable.dbr",
 			InDescription: "TestMerchantTable",
-			Out: &table{
-				Path:    "test/Amulet/MerchantTable.dbr",
-				Headers: []byte("templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestMerchantTable,\n"),
-				Body:    []byte("lootName1,test/Amulet/ItemTable.dbr,\nlootWeight1,100,\n"),
+			InItemTables:  []WeightedRecord{{Record: "test/Amulet/ItemTable.dbr"}},
+			OutPath:       "test/Amulet/MerchantTable.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestMerchantTable,\n" +
+				"lootName1,test/Amulet/ItemTable.dbr,\nlootWeight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:          "ValidTableMultipleItems",
+			InPath:        "test/MerchantTable.dbr",
+			InDescription: "TestEquipment",
+			InItemTables: []WeightedRecord{
+				{Record: "test/Amulet/ItemTable.dbr", Weight: 25},
+				{Record: "test/Head/ItemTable.dbr"},
 			},
+			OutPath: "test/MerchantTable.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestEquipment,\n" +
+				"lootName1,test/Amulet/ItemTable.dbr,\nlootWeight1,25,\n" +
+				"lootName2,test/Head/ItemTable.dbr,\nlootWeight2,100,\n",
 			OK: true,
 		},
 	}
 	for _, td := range testData {
 		t.Run(td.Name, func(t *testing.T) {
-			table, err := createMerchantTable(td.InPath, td.InItemPath, td.InDescription)
+			table, err := createMerchantTable(td.InPath, td.InDescription, td.InItemTables)
 			if err != nil && td.OK {
 				t.Errorf("unexpected error: %v", err)
 			}
 			if err == nil && !td.OK {
 				t.Error("expected error but got nil")
 			}
-			diff := deep.Equal(*table, *td.Out)
-			if diff != nil {
-				fmt.Println(*table)
+			if table.Path != td.OutPath {
+				t.Errorf("expected path %s got %s instead", td.OutPath, table.Path)
+			}
+			if diff := deep.Equal(string(table.Record.Bytes()), td.Out); diff != nil {
 				t.Errorf("result differs from expected table: %+v", diff)
 			}
 		})
 	}
 }
 
+func TestCreateLootTable(t *testing.T) {
+	table, err := createLootTable("test/Amulet/relicTable.dbr", []WeightedRecord{
+		{Name: "TestRelic", Record: "records/item/relics/testrelic.dbr"},
+		{Name: "TestCharm", Record: "records/item/relics/testcharm.dbr", Weight: 25},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table.Path != "test/Amulet/relicTable.dbr" {
+		t.Errorf("expected path test/Amulet/relicTable.dbr got %s instead", table.Path)
+	}
+	expected := "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestRelic/TestCharm,\n" +
+		"lootName1,records/item/relics/testrelic.dbr,\nlootWeight1,100,\n" +
+		"lootName2,records/item/relics/testcharm.dbr,\nlootWeight2,25,\n"
+	if diff := deep.Equal(string(table.Record.Bytes()), expected); diff != nil {
+		t.Errorf("result differs from expected table: %+v", diff)
+	}
+}
+
 func TestFlush(t *testing.T) {
 	testData := []struct {
 		Name string
@@ -488,7 +787,7 @@ func TestFlush(t *testing.T) {
 				TablePath: `tmp\test_equip`,
 				Items: []Item{
 					{
-						SlotIdentifier: "Amulet",
+						SlotIdentifier: Amulet,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -508,7 +807,7 @@ func TestFlush(t *testing.T) {
 				TablePath: `tmp\test_equip`,
 				Items: []Item{
 					{
-						SlotIdentifier: "Amulet",
+						SlotIdentifier: Amulet,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -517,7 +816,7 @@ func TestFlush(t *testing.T) {
 						SuffixRecord:   "Test/SuffixRecord/record.dbr",
 					},
 					{
-						SlotIdentifier: "Head",
+						SlotIdentifier: Head,
 						BaseName:       "TestBaseName",
 						BaseRecord:     "Test/BaseRecord/record.dbr",
 						PrefixName:     "TestPrefixName",
@@ -530,6 +829,26 @@ func TestFlush(t *testing.T) {
 			Out: nil,
 			OK:  true,
 		},
+		{
+			Name: "TestHappyPathBroken",
+			In: &Equipment{
+				Name:      "TestEquipment",
+				TablePath: `tmp\test_equip`,
+				Items: []Item{
+					{
+						SlotIdentifier: Amulet,
+						BaseName:       "TestBaseName",
+						BaseRecord:     "Test/BaseRecord/record.dbr",
+						BrokenOnly:     true,
+						BrokenAffixes: []WeightedRecord{
+							{Name: "Broken", Record: "Test/BrokenRecord/record.dbr"},
+						},
+					},
+				},
+			},
+			Out: nil,
+			OK:  true,
+		},
 		{
 			Name: "TestNoItems",
 			In: &Equipment{
@@ -555,9 +874,14 @@ func TestFlush(t *testing.T) {
 			if err == nil && !td.OK {
 				t.Error("expected error but got nil")
 			}
+			if len(td.In.Items) > 0 {
+				if _, err := os.Stat(filepath.Join(td.In.FolderPath, td.In.TablePath, "merchantTable.dbr")); os.IsNotExist(err) {
+					t.Error("path to the equipment merchant table does not exist")
+				}
+			}
 			for _, item := range td.In.Items {
 				for _, table := range []string{"merchantTable.dbr", "itemTable.dbr", "itemPrefixTable.dbr", "itemSuffixTable.dbr"} {
-					if _, err := os.Stat(filepath.Join(td.In.FolderPath, td.In.TablePath, item.SlotIdentifier, table)); os.IsNotExist(err) {
+					if _, err := os.Stat(filepath.Join(td.In.FolderPath, td.In.TablePath, item.SlotIdentifier.String(), table)); os.IsNotExist(err) {
 						t.Errorf("path to table %s does not exist", table)
 					}
 				}
@@ -566,3 +890,321 @@ func TestFlush(t *testing.T) {
 		})
 	}
 }
+
+func TestFlushDatabase(t *testing.T) {
+	dir := t.TempDir()
+	e := &Equipment{
+		Name:               "TestEquipment",
+		FolderPath:         filepath.Join(dir, "database"),
+		TablePath:          `records\test_equip`,
+		OutputDatabasePath: filepath.Join(dir, "database.arz"),
+		RecordFolders:      []string{"../testData/database"},
+		Merchants:          []string{`records\creature\npc\merchant\testmerchant.dbr`},
+		Items: []Item{
+			{
+				SlotIdentifier: Amulet,
+				BaseName:       "TestBaseName",
+				BaseRecord:     "records/item/equipmentamulet/testamulet.dbr",
+				PrefixName:     "TestPrefixName",
+				PrefixRecord:   "records/item/lootmagicalaffixes/prefix/default/testprefix.dbr",
+				SuffixName:     "TestSuffixName",
+				SuffixRecord:   "records/item/lootmagicalaffixes/suffix/default/testsuffix.dbr",
+			},
+		},
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	db, err := arz.Open(e.OutputDatabasePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, record := range []string{
+		filepath.Join(e.TablePath, "Amulet", "merchantTable.dbr"),
+		filepath.Join(e.TablePath, "Amulet", "itemTable.dbr"),
+		filepath.Join(e.TablePath, "Amulet", "itemPrefixTable.dbr"),
+		filepath.Join(e.TablePath, "Amulet", "itemSuffixTable.dbr"),
+		e.Items[0].BaseRecord,
+	} {
+		if !db.Has(record) {
+			t.Errorf("record %s is missing in the compiled database", record)
+		}
+	}
+	merchant, err := db.Record(e.Merchants[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if table, _ := merchant.Get("marketTableName"); table != arz.CleanPath(e.merchantTablePath()) {
+		t.Errorf("merchant sells from %s instead of the patched table", table)
+	}
+}
+
+func TestFlushText(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	e := &Equipment{
+		Name:           "Test Equipment",
+		FolderPath:     filepath.Join(dir, "database"),
+		TablePath:      "records/test_equip",
+		OutputTextPath: filepath.Join(dir, "Text_EN.arc"),
+		Tags:           map[string]string{"tagCustom": "Custom"},
+		Items: []Item{
+			{
+				SlotIdentifier: Amulet,
+				BaseName:       "Test Amulet",
+				BaseRecord:     `records\item\equipmentamulet\testamulet.dbr`,
+				SuffixName:     "TestSuffixName",
+				SuffixRecord:   "Test/SuffixRecord/record.dbr",
+			},
+			{
+				SlotIdentifier: RingLeft,
+				Bases: []WeightedRecord{
+					{Name: "Test Ring", Record: `records\item\equipmentring\testsetring.dbr`},
+					{Record: `records\item\equipmentring\testsetring.dbr`, Weight: 50},
+				},
+			},
+			{
+				SlotIdentifier: RingRight,
+				BaseName:       "Test Ring",
+				BaseRecord:     `records\item\equipmentring\testsetring.dbr`,
+			},
+		},
+		db: db,
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, err := arc.Open(e.OutputTextPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := a.Read("testequipment.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := tags.Parse(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := tags.Tags{
+		"tagTestEquipmentTestAmulet": "Test Amulet",
+		"tagTestEquipmentTestRing":   "Test Ring",
+		"tagCustom":                  "Custom",
+	}
+	if diff := deep.Equal(out, expected); diff != nil {
+		t.Errorf("result differs from expected tags: %+v", diff)
+	}
+	base, err := dbr.ParseFile(filepath.Join(e.FolderPath, "records", "test_equip", "Amulet", "base1.dbr"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedBase := "templateName,database\\Templates\\JewelryAmulet.tpl,\nClass,ArmorJewelry_Amulet,\nFileDescription,Test amulet,\n" +
+		"itemNameTag,tagTestEquipmentTestAmulet,\nitemLevel,20,\n"
+	if diff := deep.Equal(string(base.Bytes()), expectedBase); diff != nil {
+		t.Errorf("result differs from expected base: %+v", diff)
+	}
+	itemTable, err := dbr.ParseFile(filepath.Join(e.FolderPath, "records", "test_equip", "RingLeft", "itemTable.dbr"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for field, expectedBase := range map[string]string{
+		"lootName1": filepath.Join("records", "test_equip", "RingLeft", "base1.dbr"),
+		"lootName2": `records\item\equipmentring\testsetring.dbr`,
+	} {
+		if got, _ := itemTable.Get(field); got != expectedBase {
+			t.Errorf("expected %s %s got %s instead", field, expectedBase, got)
+		}
+	}
+}
+
+func TestFlushTextSharedTag(t *testing.T) {
+	dir := t.TempDir()
+	e := &Equipment{
+		Name:           "Test Equipment",
+		FolderPath:     filepath.Join(dir, "database"),
+		OutputTextPath: filepath.Join(dir, "Text_EN.arc"),
+		Items: []Item{
+			{SlotIdentifier: RingLeft, BaseName: "Test Ring", BaseRecord: "Test/BaseRecord/ring.dbr"},
+			{SlotIdentifier: RingRight, BaseName: "Test-Ring", BaseRecord: "Test/BaseRecord/ring.dbr"},
+		},
+	}
+	if err := e.Flush(); err == nil {
+		t.Error("expected error but got nil")
+	}
+	if _, err := os.Stat(e.FolderPath); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written but got %v", err)
+	}
+}
+
+func TestCategoryOfTable(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "Shield",
+			In:   `records\item\lootmagicalaffixes\suffix\tablesshields\tableshield_b.dbr`,
+			Out:  shieldCategory,
+		},
+		{
+			Name: "Jewellery",
+			In:   "records/item/LootMagicalAffixes/Prefix/TablesRings/table.dbr",
+			Out:  jewelleryCategory,
+		},
+		{
+			Name: "Weapon",
+			In:   `records\item\lootmagicalaffixes\prefix\tablesweapons\sword_a.dbr`,
+			Out:  weaponCategory,
+		},
+		{
+			Name: "Unknown",
+			In:   `records\item\lootmagicalaffixes\prefix\default\table.dbr`,
+			Out:  "",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			if c := categoryOfTable(td.In); c != td.Out {
+				t.Errorf("expected %s got %s instead", td.Out, c)
+			}
+		})
+	}
+}
+
+func TestFlushMerchants(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name       string
+		InMerchant string
+		InLoose    string
+		OutField   string
+		OK         bool
+	}{
+		{
+			Name:       "DatabaseMerchant",
+			InMerchant: `records\creature\npc\merchant\testmerchant.dbr`,
+			OutField:   "marketTableName",
+			OK:         true,
+		},
+		{
+			Name:       "LooseMerchant",
+			InMerchant: "records/creature/npc/merchant/loosemerchant.dbr",
+			InLoose:    "Class,Merchant,\nmarketTable,records\\item\\merchant\\vanillatable.dbr,\n",
+			OutField:   "marketTable",
+			OK:         true,
+		},
+		{
+			Name:       "MissingMerchant",
+			InMerchant: "records/creature/npc/merchant/idonotexist.dbr",
+			OK:         false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{
+				Name:       "TestEquipment",
+				FolderPath: t.TempDir(),
+				TablePath:  "records/test_equip",
+				Merchants:  []string{td.InMerchant},
+				Items: []Item{
+					{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/record.dbr"},
+				},
+				db: db,
+			}
+			file := filepath.Join(e.FolderPath, recordFile(td.InMerchant))
+			if td.InLoose != "" {
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if err := ioutil.WriteFile(file, []byte(td.InLoose), 0644); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			err := e.Flush()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				return
+			}
+			r, err := dbr.ParseFile(file)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if v, _ := r.Get(td.OutField); v != e.merchantTablePath() {
+				t.Errorf("expected %s to be %s got %s instead", td.OutField, e.merchantTablePath(), v)
+			}
+			if class, _ := r.Get("Class"); class != "Merchant" {
+				t.Errorf("expected the merchant record to be copied got class %s instead", class)
+			}
+		})
+	}
+}
+
+func TestFlushMonsters(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	half := 50.0
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Monsters: []MonsterLoot{
+			{Record: `records\creature\monster\test\testmonster.dbr`, Chance: &half, Slots: []Slot{Head, RingLeft}},
+			{Record: `records\creature\monster\test\testmonsternoweight.dbr`, Slots: []Slot{Head}},
+		},
+		Items: []Item{
+			{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/amulet.dbr"},
+			{SlotIdentifier: Head, BaseRecord: "Test/BaseRecord/helm.dbr"},
+		},
+		db: db,
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	helmTable := filepath.Join("records", "test_equip", "Head", "itemTable.dbr")
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "Monster",
+			In:   filepath.Join("records", "creature", "monster", "test", "testmonster.dbr"),
+			Out: "templateName,database\\Templates\\Monster.tpl,\nClass,Monster,\nFileDescription,Test monster,\n" +
+				"chanceToEquipHead,50.000000,\nchanceToEquipHeadItem1,100.000000,\nchanceToEquipHeadItem2,0.000000,\n" +
+				"lootHeadItem1," + helmTable + ",\nlootHeadItem2,,\n" +
+				"chanceToEquipMisc1,30.000000,\nlootMisc1Item1,records\\item\\equipmentamulet\\testamulet.dbr,\n",
+		},
+		{
+			Name: "MonsterWithoutWeight",
+			In:   filepath.Join("records", "creature", "monster", "test", "testmonsternoweight.dbr"),
+			Out: "templateName,database\\Templates\\Monster.tpl,\nClass,Monster,\nFileDescription,Test monster without a first head weight,\n" +
+				"chanceToEquipHead,100.000000,\nchanceToEquipHeadItem2,0.000000,\nlootHeadItem2,,\n" +
+				"lootHeadItem1," + helmTable + ",\nchanceToEquipHeadItem1,100.000000,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := dbr.ParseFile(filepath.Join(e.FolderPath, td.In))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(string(r.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected monster: %+v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package equipment
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Masteries of the game.
+const (
+	Warfare = "Warfare"
+	Defense = "Defense"
+	Hunting = "Hunting"
+	Rogue   = "Rogue"
+	Earth   = "Earth"
+	Storm   = "Storm"
+	Nature  = "Nature"
+	Spirit  = "Spirit"
+	Dream   = "Dream"
+	Rune    = "Rune"
+	Neidan  = "Neidan"
+)
+
+var masteries = []string{Warfare, Defense, Hunting, Rogue, Earth, Storm, Nature, Spirit, Dream, Rune, Neidan}
+
+// rightHandSlots and leftHandSlots are the slots that hold the weapons of a character.
+var (
+	rightHandSlots = []Slot{WeaponRight, Bow, Thrown}
+	leftHandSlots  = []Slot{WeaponLeft, Shield}
+)
+
+// twoHandedClasses are the weapon classes that occupy both hands.
+var twoHandedClasses = []string{spearClass, bowClass, staffClass}
+
+// dualWieldClasses are the weapon classes that can be wielded in the left hand, which is a skill of the Warfare mastery.
+var dualWieldClasses = []string{swordClass, axeClass, maceClass}
+
+func validateMastery(mastery string) error {
+	if !contains(masteries, mastery) {
+		return fmt.Errorf("unexpected mastery %s", mastery)
+	}
+	return nil
+}
+
+func slotIn(slot Slot, slots []Slot) bool {
+	for _, s := range slots {
+		if s == slot {
+			return true
+		}
+	}
+	return false
+}
+
+// itemClasses returns the classes of the base records of an item.
+// Without the game database the classes are unknown.
+func (e *Equipment) itemClasses(i Item) []string {
+	if e.db == nil {
+		return nil
+	}
+	var classes []string
+	for _, base := range i.bases() {
+		if class, ok := e.db.Class(base.Record); ok {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// anyClass reports whether one of the classes is in want.
+func anyClass(classes, want []string) bool {
+	for _, c := range classes {
+		if contains(want, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// hands returns the items that go into the right and into the left hand.
+func (e *Equipment) hands() ([]Item, []Item) {
+	var right, left []Item
+	for _, i := range e.Items {
+		switch {
+		case slotIn(i.SlotIdentifier, rightHandSlots):
+			right = append(right, i)
+		case slotIn(i.SlotIdentifier, leftHandSlots):
+			left = append(left, i)
+		}
+	}
+	return right, left
+}
+
+// handConflicts returns all reasons why the weapons of the equipment cannot be wielded together.
+// Rules that need the classes of the base records are only checked with the game database.
+func (e *Equipment) handConflicts() []string {
+	var conflicts []string
+	right, left := e.hands()
+	for _, hand := range []struct {
+		name  string
+		items []Item
+	}{{"right", right}, {"left", left}} {
+		if len(hand.items) > 1 {
+			var slots []string
+			for _, i := range hand.items {
+				slots = append(slots, i.SlotIdentifier.String())
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s all go into the %s hand", strings.Join(slots, ", "), hand.name))
+		}
+	}
+	for _, r := range right {
+		classes := e.itemClasses(r)
+		if contains(classes, shieldClass) {
+			conflicts = append(conflicts, fmt.Sprintf("shields only go into the left hand but %s holds one", r.SlotIdentifier))
+		}
+		twoHanded := r.SlotIdentifier == Bow || anyClass(classes, twoHandedClasses)
+		for _, l := range left {
+			if twoHanded {
+				conflicts = append(conflicts, fmt.Sprintf("the two-handed weapon in %s leaves no hand for %s", r.SlotIdentifier, l.SlotIdentifier))
+			}
+		}
+	}
+	for _, l := range left {
+		if anyClass(e.itemClasses(l), twoHandedClasses) {
+			conflicts = append(conflicts, fmt.Sprintf("two-handed weapons do not go into %s", l.SlotIdentifier))
+		}
+	}
+	return conflicts
+}
+
+// verifyDualWield reports weapons in both hands if the masteries of the equipment are set and lack Warfare.
+func (e *Equipment) verifyDualWield() error {
+	if len(e.Masteries) == 0 || contains(e.Masteries, Warfare) {
+		return nil
+	}
+	right, left := e.hands()
+	for _, l := range left {
+		if !anyClass(e.itemClasses(l), dualWieldClasses) {
+			continue
+		}
+		for _, r := range right {
+			if err := e.warnf("dual wielding %s and %s of %s needs the %s mastery", r.SlotIdentifier, l.SlotIdentifier, e.TablePath, Warfare); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
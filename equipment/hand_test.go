@@ -0,0 +1,176 @@
+package equipment
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+)
+
+func TestValidate(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sword := "records/item/equipmentweapon/sword/testsword.dbr"
+	staff := "records/item/equipmentweapon/staff/teststaff.dbr"
+	shield := "records/item/equipmentshield/testshield.dbr"
+	testData := []struct {
+		Name         string
+		InItems      []Item
+		InMasteries  []string
+		InDifficulty []Difficulty
+		InNoDatabase bool
+		InStrict     bool
+		OK           bool
+	}{
+		{
+			Name: "SwordAndShield",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: Shield, BaseRecord: shield},
+			},
+			OK: true,
+		},
+		{
+			Name: "ShieldInWeaponLeft",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: WeaponLeft, BaseRecord: shield},
+			},
+			OK: true,
+		},
+		{
+			Name: "ShieldInRightHand",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: shield},
+			},
+			OK: false,
+		},
+		{
+			Name: "StaffAndShield",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: staff},
+				{SlotIdentifier: Shield, BaseRecord: shield},
+			},
+			OK: false,
+		},
+		{
+			Name: "StaffInLeftHand",
+			InItems: []Item{
+				{SlotIdentifier: WeaponLeft, BaseRecord: staff},
+			},
+			OK: false,
+		},
+		{
+			Name: "BowSlotAndShieldWithoutDatabase",
+			InItems: []Item{
+				{SlotIdentifier: Bow, BaseRecord: "Test/BaseRecord/bow.dbr"},
+				{SlotIdentifier: Shield, BaseRecord: "Test/BaseRecord/shield.dbr"},
+			},
+			InNoDatabase: true,
+			OK:           false,
+		},
+		{
+			Name: "TwoRightHands",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: Thrown, BaseRecord: "Test/BaseRecord/thrown.dbr"},
+			},
+			OK: false,
+		},
+		{
+			Name: "DualWieldWithoutWarfare",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: WeaponLeft, BaseRecord: sword},
+			},
+			InMasteries: []string{Defense},
+			InStrict:    true,
+			OK:          false,
+		},
+		{
+			Name: "DualWieldWithoutWarfareWarning",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: WeaponLeft, BaseRecord: sword},
+			},
+			InMasteries: []string{Defense},
+			OK:          true,
+		},
+		{
+			Name: "DualWieldWithoutMasteries",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: WeaponLeft, BaseRecord: sword},
+			},
+			InStrict: true,
+			OK:       true,
+		},
+		{
+			Name: "DualWieldWithWarfare",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: WeaponLeft, BaseRecord: sword},
+			},
+			InMasteries: []string{Warfare, Defense},
+			OK:          true,
+		},
+		{
+			Name:        "UnknownMastery",
+			InItems:     []Item{{SlotIdentifier: WeaponRight, BaseRecord: sword}},
+			InMasteries: []string{"Cooking"},
+			OK:          false,
+		},
+		{
+			Name: "DifficultyConflict",
+			InItems: []Item{
+				{SlotIdentifier: WeaponRight, BaseRecord: sword},
+				{SlotIdentifier: Shield, BaseRecord: shield},
+			},
+			InDifficulty: []Difficulty{
+				{Name: "Epic", Items: []Item{{SlotIdentifier: WeaponRight, BaseRecord: staff}}},
+			},
+			OK: false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{
+				Items:        td.InItems,
+				Masteries:    td.InMasteries,
+				Difficulties: td.InDifficulty,
+				Strict:       td.InStrict,
+			}
+			if !td.InNoDatabase {
+				e.db = db
+			}
+			err := e.Validate()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestFlushConflicts(t *testing.T) {
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Items: []Item{
+			{SlotIdentifier: Bow, BaseRecord: "Test/BaseRecord/bow.dbr"},
+			{SlotIdentifier: Shield, BaseRecord: "Test/BaseRecord/shield.dbr"},
+		},
+	}
+	if err := e.Flush(); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	if _, err := os.Stat(filepath.Join(e.FolderPath, "records")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written but got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package equipment
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+// Inspect writes the fields of the record at path and of all records it references to w.
+// Referenced records are indented below the field that references them
+// and every record is only written once.
+func (e *Equipment) Inspect(w io.Writer, path string) error {
+	rec, err := e.Record(path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, path)
+	e.inspectFields(w, rec, 1, map[string]bool{arz.CleanPath(path): true})
+	return nil
+}
+
+// inspectFields writes the fields of rec and follows all references that were not visited yet.
+// References that cannot be read are reported in place.
+func (e *Equipment) inspectFields(w io.Writer, rec *dbr.Record, depth int, visited map[string]bool) {
+	indent := strings.Repeat("\t", depth)
+	for _, f := range rec.Fields() {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, f.Key, f.Value)
+		for _, v := range f.Values() {
+			if !strings.HasSuffix(strings.ToLower(v), ".dbr") {
+				continue
+			}
+			if visited[arz.CleanPath(v)] {
+				fmt.Fprintf(w, "%s\t%s (see above)\n", indent, v)
+				continue
+			}
+			visited[arz.CleanPath(v)] = true
+			ref, err := e.Record(v)
+			if err != nil {
+				fmt.Fprintf(w, "%s\t%s (%v)\n", indent, v, err)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\n", indent, v)
+			e.inspectFields(w, ref, depth+2, visited)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package equipment
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/go-test/deep"
+)
+
+func TestInspect(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	folder := t.TempDir()
+	loose := "records/test_equip/loose.dbr"
+	if err := os.MkdirAll(filepath.Join(folder, filepath.Dir(loose)), 0755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\n" +
+		"lootName1,records\\item\\relics\\testrelic.dbr,\n" +
+		"lootName2,records\\test_equip\\loose.dbr;records\\item\\missing.dbr,\n"
+	if err := ioutil.WriteFile(filepath.Join(folder, loose), []byte(content), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Equipment{FolderPath: folder, db: db}
+	testData := []struct {
+		Name          string
+		In            string
+		Out           string
+		ExpectedError bool
+	}{
+		{
+			Name: "DatabaseRecord",
+			In:   `records\item\relics\testrelic.dbr`,
+			Out: "records\\item\\relics\\testrelic.dbr\n" +
+				"\ttemplateName: database\\Templates\\ItemRelic.tpl\n" +
+				"\tClass: ItemRelic\n" +
+				"\tFileDescription: Test relic\n" +
+				"\tdescription: tagTestRelic\n" +
+				"\tcompletedRelicLevel: 3\n" +
+				"\tbonusTableName: records\\item\\lootmagicalaffixes\\completionbonus\\testrelicbonus.dbr\n" +
+				"\t\trecords\\item\\lootmagicalaffixes\\completionbonus\\testrelicbonus.dbr\n" +
+				"\t\t\ttemplateName: database\\Templates\\LootRandomizer.tpl\n" +
+				"\t\t\tClass: LootRandomizer\n" +
+				"\t\t\tlootRandomizerName: tagTestRelicBonus\n" +
+				"\t\t\tcharacterStrength: 20\n",
+		},
+		{
+			Name: "LooseRecord",
+			In:   loose,
+			Out: "records/test_equip/loose.dbr\n" +
+				"\ttemplateName: database\\Templates\\LootItemTable_FixedWeight.tpl\n" +
+				"\tlootName1: records\\item\\relics\\testrelic.dbr\n" +
+				"\t\trecords\\item\\relics\\testrelic.dbr\n" +
+				"\t\t\ttemplateName: database\\Templates\\ItemRelic.tpl\n" +
+				"\t\t\tClass: ItemRelic\n" +
+				"\t\t\tFileDescription: Test relic\n" +
+				"\t\t\tdescription: tagTestRelic\n" +
+				"\t\t\tcompletedRelicLevel: 3\n" +
+				"\t\t\tbonusTableName: records\\item\\lootmagicalaffixes\\completionbonus\\testrelicbonus.dbr\n" +
+				"\t\t\t\trecords\\item\\lootmagicalaffixes\\completionbonus\\testrelicbonus.dbr\n" +
+				"\t\t\t\t\ttemplateName: database\\Templates\\LootRandomizer.tpl\n" +
+				"\t\t\t\t\tClass: LootRandomizer\n" +
+				"\t\t\t\t\tlootRandomizerName: tagTestRelicBonus\n" +
+				"\t\t\t\t\tcharacterStrength: 20\n" +
+				"\tlootName2: records\\test_equip\\loose.dbr;records\\item\\missing.dbr\n" +
+				"\t\trecords\\test_equip\\loose.dbr (see above)\n" +
+				"\t\trecords\\item\\missing.dbr (record records\\item\\missing.dbr does not exist)\n",
+		},
+		{
+			Name:          "MissingRecord",
+			In:            `records\item\missing.dbr`,
+			ExpectedError: true,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := e.Inspect(&out, td.In)
+			if td.ExpectedError {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(out.String(), td.Out); diff != nil {
+				t.Error(diff)
+			}
+		})
+	}
+}
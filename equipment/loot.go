@@ -0,0 +1,145 @@
+package equipment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+// Container is a chest like a treasure chest or a sarcophagus that drops the items of the equipment.
+type Container struct {
+	// Name is the file name of the generated container record below TablePath.
+	Name string `yaml:"Name"`
+	// Record is an existing container of the game that gets copied into FolderPath
+	// and patched to drop the items instead of generating a new container.
+	Record string `yaml:"Record"`
+	// Chance is the percentage that the container drops any items at all.
+	// It defaults to 100.
+	Chance *float64 `yaml:"Chance"`
+	// MinDrops and MaxDrops are the number of dropped items, both default to 1.
+	MinDrops int `yaml:"MinDrops"`
+	MaxDrops int `yaml:"MaxDrops"`
+	// Slots limits the dropped items to the ones of these slots.
+	// All items are dropped if it is empty.
+	Slots []Slot `yaml:"Slots"`
+}
+
+// Validate validates the container configuration.
+func (c *Container) Validate() error {
+	if c.Name == "" && c.Record == "" {
+		return fmt.Errorf("container needs a Name or a Record")
+	}
+	if chance := percentage(c.Chance); chance < 0 || chance > 100 {
+		return fmt.Errorf("Chance %v is not a percentage", chance)
+	}
+	if c.MinDrops < 0 || c.MaxDrops < 0 || c.maxDrops() < c.minDrops() {
+		return fmt.Errorf("drop count %d-%d is not valid", c.MinDrops, c.MaxDrops)
+	}
+	return validateSlots(c.Slots)
+}
+
+func (c *Container) minDrops() int {
+	if c.MinDrops == 0 {
+		return 1
+	}
+	return c.MinDrops
+}
+
+func (c *Container) maxDrops() int {
+	if c.MaxDrops == 0 {
+		return c.minDrops()
+	}
+	return c.MaxDrops
+}
+
+func (c *Container) description() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Record
+}
+
+// validateSlots checks that all slots of a slot filter exist.
+func validateSlots(slots []Slot) error {
+	for _, slot := range slots {
+		if err := slot.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slotsInclude reports whether a slot filter includes the item.
+// An empty filter includes every item.
+func slotsInclude(slots []Slot, item Item) bool {
+	if len(slots) == 0 {
+		return true
+	}
+	for _, slot := range slots {
+		if slot == item.SlotIdentifier {
+			return true
+		}
+	}
+	return false
+}
+
+func createContainerTable(path string, container Container, itemTables []WeightedRecord) (*table, error) {
+	record, err := createTableHeader("containerTable", container.description())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
+	}
+	setContainerLoot(record, container, itemTables)
+	t := table{
+		Path:   path,
+		Record: record,
+	}
+	return &t, nil
+}
+
+// setContainerLoot sets the loot fields of a container record.
+func setContainerLoot(record *dbr.Record, container Container, itemTables []WeightedRecord) {
+	record.Set("numSpawnMin", strconv.Itoa(container.minDrops()))
+	record.Set("numSpawnMax", strconv.Itoa(container.maxDrops()))
+	record.Set("loot1Chance", fmt.Sprintf("%f", percentage(container.Chance)))
+	for n, itemTable := range itemTables {
+		record.Set(fmt.Sprintf("loot1Name%d", n+1), itemTable.Record)
+		record.Set(fmt.Sprintf("loot1Weight%d", n+1), strconv.Itoa(itemTable.weight()))
+	}
+}
+
+// createContainers writes all generated containers and patches the copied ones.
+func (e *Equipment) createContainers() error {
+	for _, container := range e.Containers {
+		var itemTables []WeightedRecord
+		for _, item := range e.Items {
+			if slotsInclude(container.Slots, item) {
+				itemTables = append(itemTables, WeightedRecord{Record: e.slotTablePath(item, "itemTable.dbr")})
+			}
+		}
+		if container.Record != "" {
+			rec, err := e.Record(container.Record)
+			if err != nil {
+				return fmt.Errorf("failed to load container %s: %v", container.Record, err)
+			}
+			setContainerLoot(rec, container, itemTables)
+			if err := e.override(container.Record, rec); err != nil {
+				return fmt.Errorf("failed to write container %s: %v", container.Record, err)
+			}
+			continue
+		}
+		containerTable, err := createContainerTable(filepath.Join(e.TablePath, "containers", container.Name+".dbr"), container, itemTables)
+		if err != nil {
+			return fmt.Errorf("failed to initialise container %s: %v", container.Name, err)
+		}
+		if err := os.MkdirAll(filepath.Join(e.FolderPath, filepath.Dir(containerTable.Path)), 0755); err != nil {
+			return fmt.Errorf("failed to create folder for %s: %v", containerTable.Path, err)
+		}
+		if err := containerTable.write(e.FolderPath); err != nil {
+			return fmt.Errorf("failed to write table to %s: %v", containerTable.Path, err)
+		}
+	}
+	return nil
+}
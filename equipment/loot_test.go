@@ -0,0 +1,129 @@
+package equipment
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestCreateContainerTable(t *testing.T) {
+	half := 50.0
+	testData := []struct {
+		Name         string
+		InPath       string
+		InContainer  Container
+		InItemTables []WeightedRecord
+		OutPath      string
+		Out          string
+		OK           bool
+	}{
+		{
+			Name:        "ValidTableDefaults",
+			InPath:      "test/containers/TestChest.dbr",
+			InContainer: Container{Name: "TestChest"},
+			InItemTables: []WeightedRecord{
+				{Record: "test/Amulet/itemTable.dbr"},
+			},
+			OutPath: "test/containers/TestChest.dbr",
+			Out: "templateName,database\\Templates\\FixedItemContainer.tpl,\nActorName,,\nClass,FixedItemContainer,\nFileDescription,TestChest,\n" +
+				"numSpawnMin,1,\nnumSpawnMax,1,\nloot1Chance,100.000000,\n" +
+				"loot1Name1,test/Amulet/itemTable.dbr,\nloot1Weight1,100,\n",
+			OK: true,
+		},
+		{
+			Name:        "ValidTableMultipleItems",
+			InPath:      "test/containers/TestChest.dbr",
+			InContainer: Container{Name: "TestChest", Chance: &half, MinDrops: 2, MaxDrops: 3},
+			InItemTables: []WeightedRecord{
+				{Record: "test/Amulet/itemTable.dbr"},
+				{Record: "test/Head/itemTable.dbr"},
+			},
+			OutPath: "test/containers/TestChest.dbr",
+			Out: "templateName,database\\Templates\\FixedItemContainer.tpl,\nActorName,,\nClass,FixedItemContainer,\nFileDescription,TestChest,\n" +
+				"numSpawnMin,2,\nnumSpawnMax,3,\nloot1Chance,50.000000,\n" +
+				"loot1Name1,test/Amulet/itemTable.dbr,\nloot1Weight1,100,\n" +
+				"loot1Name2,test/Head/itemTable.dbr,\nloot1Weight2,100,\n",
+			OK: true,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			table, err := createContainerTable(td.InPath, td.InContainer, td.InItemTables)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if table.Path != td.OutPath {
+				t.Errorf("expected path %s got %s instead", td.OutPath, table.Path)
+			}
+			if diff := deep.Equal(string(table.Record.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected table: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestFlushContainers(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Containers: []Container{
+			{Name: "TestChest", Slots: []Slot{Head}},
+			{Record: `records\item\containers\testchest.dbr`},
+		},
+		Items: []Item{
+			{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/amulet.dbr"},
+			{SlotIdentifier: Head, BaseRecord: "Test/BaseRecord/helm.dbr"},
+		},
+		db: db,
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name string
+		In   string
+		Out  []string
+	}{
+		{
+			Name: "GeneratedContainer",
+			In:   filepath.Join(e.FolderPath, "records", "test_equip", "containers", "TestChest.dbr"),
+			Out:  []string{e.slotTablePath(e.Items[1], "itemTable.dbr")},
+		},
+		{
+			Name: "CopiedContainer",
+			In:   filepath.Join(e.FolderPath, "records", "item", "containers", "testchest.dbr"),
+			Out:  []string{e.slotTablePath(e.Items[0], "itemTable.dbr"), e.slotTablePath(e.Items[1], "itemTable.dbr")},
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := dbr.ParseFile(td.In)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var out []string
+			for n := range td.Out {
+				v, _ := r.Get("loot1Name" + strconv.Itoa(n+1))
+				out = append(out, v)
+			}
+			if diff := deep.Equal(out, td.Out); diff != nil {
+				t.Errorf("result differs from expected loot: %+v", diff)
+			}
+			if class, _ := r.Get("Class"); class != containerClass {
+				t.Errorf("expected class %s got %s instead", containerClass, class)
+			}
+		})
+	}
+}
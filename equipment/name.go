@@ -0,0 +1,166 @@
+package equipment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/arc"
+	"github.com/Deichindianer/tq-item-setup/tags"
+)
+
+// Kinds of records that can be looked up by their display name.
+const (
+	baseKind   = "base"
+	prefixKind = "prefix"
+	suffixKind = "suffix"
+)
+
+// nameTagFields are the fields that hold the tag of the display name of a record by kind.
+var nameTagFields = map[string]string{
+	baseKind:   "itemNameTag",
+	prefixKind: "lootRandomizerName",
+	suffixKind: "lootRandomizerName",
+}
+
+// nameIndex maps the lower case display names of records to their paths by kind.
+type nameIndex map[string]map[string][]string
+
+// add adds a record to the index.
+func (n nameIndex) add(kind, name, record string) {
+	if n[kind] == nil {
+		n[kind] = make(map[string][]string)
+	}
+	key := strings.ToLower(strings.TrimSpace(name))
+	n[kind][key] = append(n[kind][key], record)
+}
+
+// lookup returns the record of a kind with the display name.
+// It fails if there is no such record or if the name is ambiguous.
+func (n nameIndex) lookup(kind, name string) (string, error) {
+	records := n[kind][strings.ToLower(strings.TrimSpace(name))]
+	switch len(records) {
+	case 0:
+		return "", fmt.Errorf("there is no %s named %q", kind, name)
+	case 1:
+		return records[0], nil
+	}
+	candidates := append([]string(nil), records...)
+	sort.Strings(candidates)
+	return "", fmt.Errorf("%s name %q is ambiguous, it could be any of %s", kind, name, strings.Join(candidates, ", "))
+}
+
+// recordKind returns the kind of a record of the game that can be looked up by name.
+func recordKind(path, class string) string {
+	switch {
+	case class == affixClass && strings.HasPrefix(path, affixFolder+`\prefix\`):
+		return prefixKind
+	case class == affixClass && strings.HasPrefix(path, affixFolder+`\suffix\`):
+		return suffixKind
+	case categoryOfClass(class) != "":
+		return baseKind
+	}
+	return ""
+}
+
+// names builds the name index from the game database and the text archive at TextPath.
+// Tags of the equipment take precedence over the ones of the game.
+func (e *Equipment) names() (nameIndex, error) {
+	a, err := arc.Open(e.TextPath)
+	if err != nil {
+		return nil, err
+	}
+	text, err := tags.FromArchive(a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read text of %s: %v", e.TextPath, err)
+	}
+	for k, v := range e.Tags {
+		text[k] = v
+	}
+	index := make(nameIndex)
+	for _, path := range e.db.Records() {
+		class, _ := e.db.Class(path)
+		kind := recordKind(path, class)
+		if kind == "" {
+			continue
+		}
+		rec, err := e.db.Record(path)
+		if err != nil {
+			return nil, err
+		}
+		tag, _ := rec.Get(nameTagFields[kind])
+		if name, ok := text[tag]; ok && name != "" {
+			index.add(kind, name, path)
+		}
+	}
+	return index, nil
+}
+
+// resolveNames looks up the records of all bases and affixes that only have a name.
+// Names are only labels if there is no TextPath.
+func (e *Equipment) resolveNames() error {
+	if e.TextPath == "" {
+		return nil
+	}
+	var index nameIndex
+	resolve := func(kind, name string, record *string) error {
+		if name == "" || *record != "" {
+			return nil
+		}
+		if index == nil {
+			var err error
+			if index, err = e.names(); err != nil {
+				return err
+			}
+		}
+		r, err := index.lookup(kind, name)
+		if err != nil {
+			return err
+		}
+		*record = r
+		return nil
+	}
+	items := make([]*Item, 0, len(e.Items))
+	for n := range e.Items {
+		items = append(items, &e.Items[n])
+	}
+	for k := range e.Difficulties {
+		for n := range e.Difficulties[k].Items {
+			items = append(items, &e.Difficulties[k].Items[n])
+		}
+	}
+	for _, i := range items {
+		if err := i.resolveNames(resolve); err != nil {
+			return fmt.Errorf("item of slot %s is not valid: %v", i.SlotIdentifier, err)
+		}
+	}
+	return nil
+}
+
+// resolveNames resolves the names of the bases and affixes of an item.
+func (i *Item) resolveNames(resolve func(kind, name string, record *string) error) error {
+	for _, r := range []struct {
+		kind   string
+		name   string
+		record *string
+	}{
+		{baseKind, i.BaseName, &i.BaseRecord},
+		{prefixKind, i.PrefixName, &i.PrefixRecord},
+		{suffixKind, i.SuffixName, &i.SuffixRecord},
+	} {
+		if err := resolve(r.kind, r.name, r.record); err != nil {
+			return err
+		}
+	}
+	for _, list := range []struct {
+		kind    string
+		records []WeightedRecord
+	}{{baseKind, i.Bases}, {prefixKind, i.Prefixes}, {suffixKind, i.Suffixes}} {
+		for n := range list.records {
+			if err := resolve(list.kind, list.records[n].Name, &list.records[n].Record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package equipment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/go-test/deep"
+)
+
+func TestResolveNames(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name      string
+		InItem    Item
+		InText    string
+		InTags    map[string]string
+		Out       Item
+		OutErrors []string
+		OK        bool
+	}{
+		{
+			Name:   "NothingToResolve",
+			InItem: Item{SlotIdentifier: Amulet, BaseName: "Unknown", BaseRecord: "Test/BaseRecord/amulet.dbr"},
+			Out:    Item{SlotIdentifier: Amulet, BaseName: "Unknown", BaseRecord: "Test/BaseRecord/amulet.dbr"},
+			OK:     true,
+		},
+		{
+			Name: "AllNames",
+			InItem: Item{
+				SlotIdentifier: WeaponRight,
+				BaseName:       "test sword",
+				PrefixName:     "Heavy",
+				SuffixName:     "of Ease",
+				Bases:          []WeightedRecord{{Name: "Test Staff", Weight: 10}},
+				Suffixes:       []WeightedRecord{{Name: "of the Bear"}},
+			},
+			InText: "../testData/Text_EN.arc",
+			Out: Item{
+				SlotIdentifier: WeaponRight,
+				BaseName:       "test sword",
+				BaseRecord:     `records\item\equipmentweapon\sword\testsword.dbr`,
+				PrefixName:     "Heavy",
+				PrefixRecord:   `records\item\lootmagicalaffixes\prefix\default\testlevelprefix.dbr`,
+				SuffixName:     "of Ease",
+				SuffixRecord:   `records\item\lootmagicalaffixes\suffix\default\testeasesuffix.dbr`,
+				Bases:          []WeightedRecord{{Name: "Test Staff", Record: `records\item\equipmentweapon\staff\teststaff.dbr`, Weight: 10}},
+				Suffixes:       []WeightedRecord{{Name: "of the Bear", Record: `records\item\lootmagicalaffixes\suffix\default\testsuffix.dbr`}},
+			},
+			OK: true,
+		},
+		{
+			Name:   "EquipmentTags",
+			InItem: Item{SlotIdentifier: Amulet, BaseName: "My Amulet"},
+			InText: "../testData/Text_EN.arc",
+			InTags: map[string]string{"tagTestAmulet": "My Amulet"},
+			Out:    Item{SlotIdentifier: Amulet, BaseName: "My Amulet", BaseRecord: `records\item\equipmentamulet\testamulet.dbr`},
+			OK:     true,
+		},
+		{
+			Name:   "PrefixIsNoSuffix",
+			InItem: Item{SlotIdentifier: Amulet, SuffixName: "Heavy"},
+			InText: "../testData/Text_EN.arc",
+			OutErrors: []string{
+				`there is no suffix named "Heavy"`,
+			},
+			OK: false,
+		},
+		{
+			Name:   "AmbiguousName",
+			InItem: Item{SlotIdentifier: Amulet, PrefixName: "Tenacious"},
+			InText: "../testData/Text_EN.arc",
+			OutErrors: []string{
+				`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+				`records\item\lootmagicalaffixes\prefix\default\testweaponprefix.dbr`,
+			},
+			OK: false,
+		},
+		{
+			Name:   "NoTextPath",
+			InItem: Item{SlotIdentifier: Amulet, BaseName: "Test Amulet", PrefixName: "Tenacious"},
+			Out:    Item{SlotIdentifier: Amulet, BaseName: "Test Amulet", PrefixName: "Tenacious"},
+			OK:     true,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{TextPath: td.InText, Tags: td.InTags, Items: []Item{td.InItem}, db: db}
+			err := e.resolveNames()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				for _, s := range td.OutErrors {
+					if !strings.Contains(err.Error(), s) {
+						t.Errorf("expected error %q to contain %q", err, s)
+					}
+				}
+				return
+			}
+			if diff := deep.Equal(e.Items[0], td.Out); diff != nil {
+				t.Errorf("result differs from expected item: %+v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package equipment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+// merchantTableFields are the fields of merchant NPCs that reference the table they sell from.
+var merchantTableFields = []string{"marketTable", "marketTableName"}
+
+// recordFile converts a record path into a file path relative to a record folder.
+func recordFile(path string) string {
+	return filepath.FromSlash(strings.ReplaceAll(path, "\\", "/"))
+}
+
+// Record loads the record at path.
+// Loose records below FolderPath and RecordFolders take precedence over the game database.
+func (e *Equipment) Record(path string) (*dbr.Record, error) {
+	for _, folder := range append([]string{e.FolderPath}, e.RecordFolders...) {
+		file := filepath.Join(folder, recordFile(path))
+		if _, err := os.Stat(file); err == nil {
+			return dbr.ParseFile(file)
+		}
+	}
+	if e.db == nil {
+		return nil, fmt.Errorf("record %s is no loose record and there is no database to read it from", path)
+	}
+	return e.db.Record(path)
+}
+
+// override writes rec below FolderPath so it replaces the record at path of the game.
+func (e *Equipment) override(path string, rec *dbr.Record) error {
+	t := table{
+		Path:   recordFile(path),
+		Record: rec,
+	}
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(e.FolderPath, t.Path)), 0755); err != nil {
+		return fmt.Errorf("failed to create folder for %s: %v", path, err)
+	}
+	return t.write(e.FolderPath)
+}
+
+// patchMerchants copies all merchant NPCs and makes them sell from the equipment merchant table.
+func (e *Equipment) patchMerchants() error {
+	for _, merchant := range e.Merchants {
+		rec, err := e.Record(merchant)
+		if err != nil {
+			return fmt.Errorf("failed to load merchant %s: %v", merchant, err)
+		}
+		patched := false
+		for _, field := range merchantTableFields {
+			if _, ok := rec.Get(field); ok {
+				rec.Set(field, e.merchantTablePath())
+				patched = true
+			}
+		}
+		if !patched {
+			rec.Set("marketTableName", e.merchantTablePath())
+		}
+		if err := e.override(merchant, rec); err != nil {
+			return fmt.Errorf("failed to write merchant %s: %v", merchant, err)
+		}
+	}
+	return nil
+}
+
+// patchMonsters copies all monsters and makes them equip the items of their slots.
+func (e *Equipment) patchMonsters() error {
+	for _, monster := range e.Monsters {
+		rec, err := e.Record(monster.Record)
+		if err != nil {
+			return fmt.Errorf("failed to load monster %s: %v", monster.Record, err)
+		}
+		for _, item := range e.Items {
+			if !slotsInclude(monster.Slots, item) {
+				continue
+			}
+			field := item.SlotIdentifier.monsterEquipField()
+			if field == "" {
+				continue
+			}
+			rec.Set("chanceToEquip"+field, fmt.Sprintf("%f", percentage(monster.Chance)))
+			rec.Set("loot"+field+"Item1", e.slotTablePath(item, "itemTable.dbr"))
+			rec.Set("chanceToEquip"+field+"Item1", fmt.Sprintf("%f", 100.0))
+			clearMonsterLoot(rec, field)
+		}
+		if err := e.override(monster.Record, rec); err != nil {
+			return fmt.Errorf("failed to write monster %s: %v", monster.Record, err)
+		}
+	}
+	return nil
+}
+
+// clearMonsterLoot clears the loot<Field>Item<N> entries of a monster but the first one
+// and zeroes their chanceToEquip<Field>Item<N> weights, so the monster only equips the item of the equipment.
+func clearMonsterLoot(rec *dbr.Record, field string) {
+	for _, key := range rec.Keys() {
+		switch {
+		case key == "loot"+field+"Item1" || key == "chanceToEquip"+field+"Item1":
+		case strings.HasPrefix(key, "loot"+field+"Item"):
+			rec.Set(key, "")
+		case strings.HasPrefix(key, "chanceToEquip"+field+"Item"):
+			rec.Set(key, fmt.Sprintf("%f", 0.0))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package equipment
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Quest offers the items of the equipment as quest reward.
+// Its reward table picks one of the item tables of its slots,
+// so the quest references the reward table instead of a single item.
+type Quest struct {
+	// Name is the file name of the reward table below TablePath/quests.
+	Name string `yaml:"Name"`
+	// Slots limits the choice to the items of these slots.
+	// All items are offered if it is empty.
+	Slots []Slot `yaml:"Slots"`
+}
+
+// Validate validates the quest configuration.
+func (q *Quest) Validate() error {
+	if q.Name == "" {
+		return fmt.Errorf("quest name is missing")
+	}
+	if alphanumeric(q.Name) != q.Name {
+		return fmt.Errorf("quest name %s may only contain letters and digits", q.Name)
+	}
+	return validateSlots(q.Slots)
+}
+
+// rewardTablePath returns the path of the reward table of a quest relative to FolderPath.
+func (e *Equipment) rewardTablePath(q Quest) string {
+	return filepath.Join(e.TablePath, "quests", q.Name+".dbr")
+}
+
+// rewards returns the item tables a quest offers.
+// Items that share a slot share its item table, so it is only offered once.
+func (e *Equipment) rewards(q Quest) []WeightedRecord {
+	var itemTables []WeightedRecord
+	offered := make(map[string]bool)
+	for _, item := range e.Items {
+		path := e.slotTablePath(item, "itemTable.dbr")
+		if !slotsInclude(q.Slots, item) || offered[path] {
+			continue
+		}
+		offered[path] = true
+		itemTables = append(itemTables, WeightedRecord{Name: item.description(), Record: path})
+	}
+	return itemTables
+}
+
+// verifyQuests checks that every quest offers at least one item
+// and that no two quests write the same reward table.
+func (e *Equipment) verifyQuests() error {
+	names := make(map[string]bool)
+	for _, q := range e.Quests {
+		key := strings.ToLower(q.Name)
+		if names[key] {
+			return fmt.Errorf("quest name %s is used twice", q.Name)
+		}
+		names[key] = true
+		if len(e.rewards(q)) == 0 {
+			return fmt.Errorf("quest %s offers no items", q.Name)
+		}
+	}
+	return nil
+}
+
+// createRewardTable creates the table that picks one of the item tables as reward.
+func createRewardTable(path, description string, itemTables []WeightedRecord) (*table, error) {
+	record, err := createTableHeader("rewardTable", description)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s header: %v", path, err)
+	}
+	for n, itemTable := range itemTables {
+		record.Set(fmt.Sprintf("lootName%d", n+1), itemTable.Record)
+		record.Set(fmt.Sprintf("lootWeight%d", n+1), strconv.Itoa(itemTable.weight()))
+	}
+	t := table{
+		Path:   path,
+		Record: record,
+	}
+	return &t, nil
+}
+
+// createQuestRewards writes the reward table of every quest.
+func (e *Equipment) createQuestRewards() error {
+	for _, quest := range e.Quests {
+		itemTables := e.rewards(quest)
+		rewardTable, err := createRewardTable(e.rewardTablePath(quest), recordNames(itemTables), itemTables)
+		if err != nil {
+			return fmt.Errorf("failed to initialise reward of quest %s: %v", quest.Name, err)
+		}
+		if err := os.MkdirAll(filepath.Join(e.FolderPath, filepath.Dir(rewardTable.Path)), 0755); err != nil {
+			return fmt.Errorf("failed to create folder for %s: %v", rewardTable.Path, err)
+		}
+		if err := rewardTable.write(e.FolderPath); err != nil {
+			return fmt.Errorf("failed to write table to %s: %v", rewardTable.Path, err)
+		}
+	}
+	return nil
+}
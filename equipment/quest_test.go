@@ -0,0 +1,98 @@
+package equipment
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestFlushQuests(t *testing.T) {
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Quests: []Quest{
+			{Name: "TestQuest"},
+			{Name: "TestHeadQuest", Slots: []Slot{Head}},
+		},
+		Items: []Item{
+			{SlotIdentifier: Amulet, BaseName: "TestAmulet", BaseRecord: "Test/BaseRecord/amulet.dbr"},
+			{SlotIdentifier: Head, BaseName: "TestHelm", BaseRecord: "Test/BaseRecord/helm.dbr"},
+			{SlotIdentifier: Head, BaseName: "TestOtherHelm", BaseRecord: "Test/BaseRecord/otherhelm.dbr"},
+		},
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	amuletTable := filepath.Join("records", "test_equip", "Amulet", "itemTable.dbr")
+	helmTable := filepath.Join("records", "test_equip", "Head", "itemTable.dbr")
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "AllSlots",
+			In:   "records/test_equip/quests/TestQuest.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestAmulet/TestHelm,\n" +
+				"lootName1," + amuletTable + ",\nlootWeight1,100,\n" +
+				"lootName2," + helmTable + ",\nlootWeight2,100,\n",
+		},
+		{
+			Name: "SlotFilter",
+			In:   "records/test_equip/quests/TestHeadQuest.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestHelm,\n" +
+				"lootName1," + helmTable + ",\nlootWeight1,100,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			r, err := dbr.ParseFile(filepath.Join(e.FolderPath, td.In))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(string(r.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected table: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyQuests(t *testing.T) {
+	items := []Item{{SlotIdentifier: Amulet, BaseRecord: "Test/BaseRecord/amulet.dbr"}}
+	testData := []struct {
+		Name string
+		In   []Quest
+		OK   bool
+	}{
+		{
+			Name: "Valid",
+			In:   []Quest{{Name: "TestQuest"}, {Name: "TestAmuletQuest", Slots: []Slot{Amulet}}},
+			OK:   true,
+		},
+		{
+			Name: "DuplicateName",
+			In:   []Quest{{Name: "TestQuest"}, {Name: "testquest"}},
+			OK:   false,
+		},
+		{
+			Name: "NoItems",
+			In:   []Quest{{Name: "TestQuest", Slots: []Slot{Head}}},
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{TablePath: "records/test_equip", Quests: td.In, Items: items}
+			err := e.verifyQuests()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
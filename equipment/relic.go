@@ -0,0 +1,104 @@
+package equipment
+
+import (
+	"fmt"
+)
+
+// RelicLoot is a relic or charm that drops and is sold together with an item.
+type RelicLoot struct {
+	Name   string `yaml:"Name"`
+	Record string `yaml:"Record"`
+	// Weight is the weight of the relic in the relic table of the item.
+	// A missing weight counts as 100.
+	Weight int `yaml:"Weight"`
+	// Bonuses are the completion bonuses the relic rolls from by their weight.
+	// If they are set the relic gets copied next to the tables of the item
+	// with its bonusTableName pointing at them, otherwise the relic keeps its own bonuses.
+	Bonuses []WeightedRecord `yaml:"Bonuses"`
+}
+
+// Validate validates the relic configuration.
+func (r *RelicLoot) Validate() error {
+	if r.Record == "" {
+		return fmt.Errorf("relic record is missing")
+	}
+	if err := validateWeighted([]WeightedRecord{{Record: r.Record, Weight: r.Weight}}, "relic"); err != nil {
+		return err
+	}
+	return validateWeighted(r.Bonuses, "completion bonus")
+}
+
+// createRelics writes the relic table of an item along with the completion bonus tables
+// and copies of all relics that roll from them.
+// It returns the path of the relic table or an empty path if the item has no relics.
+func (e *Equipment) createRelics(item Item) (string, error) {
+	if len(item.Relics) == 0 {
+		return "", nil
+	}
+	var relics []WeightedRecord
+	for n, relic := range item.Relics {
+		record := relic.Record
+		if len(relic.Bonuses) > 0 {
+			record = e.slotTablePath(item, fmt.Sprintf("relic%d.dbr", n+1))
+			bonusPath := e.slotTablePath(item, fmt.Sprintf("relic%dBonusTable.dbr", n+1))
+			if err := e.copyWithBonuses(relic.Record, "bonusTableName", record, bonusPath, relic.Bonuses); err != nil {
+				return "", err
+			}
+		}
+		relics = append(relics, WeightedRecord{Name: relic.Name, Record: record, Weight: relic.Weight})
+	}
+	relicTable, err := createLootTable(e.slotTablePath(item, "relicTable.dbr"), relics)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialise %s: %v", relicTable.Path, err)
+	}
+	if err := relicTable.write(e.FolderPath); err != nil {
+		return "", fmt.Errorf("failed to write table to %s: %v", relicTable.Path, err)
+	}
+	return relicTable.Path, nil
+}
+
+// copyWithBonuses writes the completion bonus table to bonusPath and a copy of record to path
+// that rolls its completion bonus from it in field.
+func (e *Equipment) copyWithBonuses(record, field, path, bonusPath string, bonuses []WeightedRecord) error {
+	bonusTable, err := createItemAffixTable(bonusPath, bonuses)
+	if err != nil {
+		return fmt.Errorf("failed to initialise %s: %v", bonusPath, err)
+	}
+	if err := bonusTable.write(e.FolderPath); err != nil {
+		return fmt.Errorf("failed to write table to %s: %v", bonusTable.Path, err)
+	}
+	rec, err := e.Record(record)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %v", record, err)
+	}
+	rec.Set(field, bonusTable.Path)
+	copied := table{
+		Path:   path,
+		Record: rec,
+	}
+	if err := copied.write(e.FolderPath); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %v", record, copied.Path, err)
+	}
+	return nil
+}
+
+// relicRecords returns all records the relics of an item reference.
+func (i *Item) relicRecords() []string {
+	var records []string
+	for _, relic := range i.Relics {
+		records = append(records, relic.Record)
+		for _, bonus := range relic.Bonuses {
+			records = append(records, bonus.Record)
+		}
+	}
+	return records
+}
+
+// relics returns the relics of an item as weighted records.
+func (i *Item) relics() []WeightedRecord {
+	var relics []WeightedRecord
+	for _, relic := range i.Relics {
+		relics = append(relics, WeightedRecord{Name: relic.Name, Record: relic.Record, Weight: relic.Weight})
+	}
+	return relics
+}
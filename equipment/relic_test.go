@@ -0,0 +1,113 @@
+package equipment
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestFlushRelics(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Items: []Item{
+			{
+				SlotIdentifier: Amulet,
+				BaseRecord:     "Test/BaseRecord/amulet.dbr",
+				Relics: []RelicLoot{
+					{
+						Name:    "TestRelic",
+						Record:  `records\item\relics\testrelic.dbr`,
+						Bonuses: []WeightedRecord{{Record: "Test/Bonus/strength.dbr"}},
+					},
+					{Name: "TestCharm", Record: "records/item/relics/testcharm.dbr", Weight: 25},
+				},
+			},
+			{SlotIdentifier: Head, BaseRecord: "Test/BaseRecord/helm.dbr"},
+		},
+		db: db,
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name string
+		In   string
+		Out  string
+	}{
+		{
+			Name: "RelicTable",
+			In:   "records/test_equip/Amulet/relicTable.dbr",
+			Out: "templateName,database\\Templates\\LootItemTable_FixedWeight.tpl,\nActorName,,\nClass,LootItemTable_FixedWeight,\nFileDescription,TestRelic/TestCharm,\n" +
+				"lootName1," + filepath.Join("records", "test_equip", "Amulet", "relic1.dbr") + ",\nlootWeight1,100,\n" +
+				"lootName2,records/item/relics/testcharm.dbr,\nlootWeight2,25,\n",
+		},
+		{
+			Name: "BonusTable",
+			In:   "records/test_equip/Amulet/relic1BonusTable.dbr",
+			Out: "templateName,database\\Templates\\LootRandomizerTable.tpl,\nActorName,,\nClass,LootRandomizerTable,\nFileDescription,,\n" +
+				"randomizerName1,Test/Bonus/strength.dbr,\nrandomizerWeight1,100,\n",
+		},
+		{
+			Name: "CopiedRelic",
+			In:   "records/test_equip/Amulet/relic1.dbr",
+			Out: "templateName,database\\Templates\\ItemRelic.tpl,\nClass,ItemRelic,\nFileDescription,Test relic,\n" +
+				"description,tagTestRelic,\ncompletedRelicLevel,3,\n" +
+				"bonusTableName," + filepath.Join("records", "test_equip", "Amulet", "relic1BonusTable.dbr") + ",\n",
+		},
+		{
+			Name: "SlotMerchantTable",
+			In:   "records/test_equip/Amulet/merchantTable.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,,\n" +
+				"lootName1," + filepath.Join("records", "test_equip", "Amulet", "itemTable.dbr") + ",\nlootWeight1,100,\n" +
+				"lootName2," + filepath.Join("records", "test_equip", "Amulet", "relicTable.dbr") + ",\nlootWeight2,100,\n",
+		},
+		{
+			Name: "EquipmentMerchantTable",
+			In:   "records/test_equip/merchantTable.dbr",
+			Out: "templateName,database\\Templates\\LootMasterTable.tpl,\nActorName,,\nClass,LootMasterTable,\nFileDescription,TestEquipment,\n" +
+				"lootName1," + filepath.Join("records", "test_equip", "Amulet", "itemTable.dbr") + ",\nlootWeight1,100,\n" +
+				"lootName2," + filepath.Join("records", "test_equip", "Amulet", "relicTable.dbr") + ",\nlootWeight2,100,\n" +
+				"lootName3," + filepath.Join("records", "test_equip", "Head", "itemTable.dbr") + ",\nlootWeight3,100,\n",
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			rec, err := dbr.ParseFile(filepath.Join(e.FolderPath, filepath.FromSlash(td.In)))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := deep.Equal(string(rec.Bytes()), td.Out); diff != nil {
+				t.Errorf("result differs from expected record: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestFlushRelicsWithoutDatabase(t *testing.T) {
+	e := &Equipment{
+		Name:       "TestEquipment",
+		FolderPath: t.TempDir(),
+		TablePath:  "records/test_equip",
+		Items: []Item{
+			{
+				SlotIdentifier: Amulet,
+				BaseRecord:     "Test/BaseRecord/amulet.dbr",
+				Relics: []RelicLoot{
+					{Record: "records/item/relics/testrelic.dbr", Bonuses: []WeightedRecord{{Record: "Test/Bonus/strength.dbr"}}},
+				},
+			},
+		},
+	}
+	if err := e.Flush(); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
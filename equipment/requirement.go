@@ -0,0 +1,249 @@
+package equipment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+// requirements are what a character needs to wear an item.
+type requirements struct {
+	level        float64
+	strength     float64
+	dexterity    float64
+	intelligence float64
+}
+
+// budget returns the requirements the character of the equipment fulfills.
+func (e *Equipment) budget() requirements {
+	return requirements{
+		level:        float64(e.Level),
+		strength:     float64(e.Strength),
+		dexterity:    float64(e.Dexterity),
+		intelligence: float64(e.Intelligence),
+	}
+}
+
+// exceeds describes all requirements that are higher than the budget.
+// Budgets of 0 are not checked.
+func (r requirements) exceeds(budget requirements) string {
+	var exceeded []string
+	for _, c := range []struct {
+		name   string
+		value  float64
+		budget float64
+	}{
+		{"level", r.level, budget.level},
+		{"strength", r.strength, budget.strength},
+		{"dexterity", r.dexterity, budget.dexterity},
+		{"intelligence", r.intelligence, budget.intelligence},
+	} {
+		if c.budget > 0 && c.value > c.budget {
+			exceeded = append(exceeded, fmt.Sprintf("%s %v > %v", c.name, c.value, c.budget))
+		}
+	}
+	return strings.Join(exceeded, ", ")
+}
+
+// recordFloat returns the numeric value of a field of a record or 0 if it is not set.
+func recordFloat(rec *dbr.Record, field string) (float64, error) {
+	v, ok := rec.Get(field)
+	if !ok || v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a number: %v", field, err)
+	}
+	return f, nil
+}
+
+// baseRequirements reads the explicit requirements of a base record.
+// Requirements that only come from the equations of its itemCostName are 0.
+func baseRequirements(rec *dbr.Record) (requirements, error) {
+	var r requirements
+	for _, f := range []struct {
+		field string
+		value *float64
+	}{
+		{"levelRequirement", &r.level},
+		{"strengthRequirement", &r.strength},
+		{"dexterityRequirement", &r.dexterity},
+		{"intelligenceRequirement", &r.intelligence},
+	} {
+		v, err := recordFloat(rec, f.field)
+		if err != nil {
+			return r, err
+		}
+		*f.value = v
+	}
+	return r, nil
+}
+
+// affixOutcomes returns the affix combinations an item can drop with.
+// An empty record stands for no affix.
+func (i *Item) affixOutcomes() [][2]string {
+	prefixes := affixChoices(i.prefixes(), i.PrefixChance)
+	suffixes := affixChoices(i.suffixes(), i.SuffixChance)
+	var outcomes [][2]string
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			// only one affix rolls if the item does not always get both
+			if prefix != "" && suffix != "" && percentage(i.BothPrefixSuffix) == 0 {
+				continue
+			}
+			outcomes = append(outcomes, [2]string{prefix, suffix})
+		}
+	}
+	if percentage(i.BothPrefixSuffix) < 100 {
+		outcomes = append(outcomes, oneSidedOutcomes(prefixes, suffixes)...)
+	}
+	return outcomes
+}
+
+// affixChoices returns the affixes that can roll plus an empty record if it is possible that none rolls.
+func affixChoices(affixes []WeightedRecord, chance *float64) []string {
+	var choices []string
+	for _, a := range affixes {
+		choices = append(choices, a.Record)
+	}
+	if len(choices) == 0 || percentage(chance) < 100 {
+		choices = append(choices, "")
+	}
+	return choices
+}
+
+// oneSidedOutcomes returns the combinations with only a prefix or only a suffix
+// that are not part of the choices already.
+func oneSidedOutcomes(prefixes, suffixes []string) [][2]string {
+	var outcomes [][2]string
+	if !contains(suffixes, "") {
+		for _, prefix := range prefixes {
+			outcomes = append(outcomes, [2]string{prefix, ""})
+		}
+	}
+	if !contains(prefixes, "") {
+		for _, suffix := range suffixes {
+			outcomes = append(outcomes, [2]string{"", suffix})
+		}
+	}
+	return outcomes
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// withAffixes applies the level requirement and the requirement reduction of the affixes.
+func withAffixes(r requirements, affixes []*dbr.Record) (requirements, error) {
+	var reduction float64
+	for _, affix := range affixes {
+		level, err := recordFloat(affix, "levelRequirement")
+		if err != nil {
+			return r, err
+		}
+		if level > r.level {
+			r.level = level
+		}
+		reduce, err := recordFloat(affix, "characterGlobalReqReduction")
+		if err != nil {
+			return r, err
+		}
+		reduction += reduce
+	}
+	factor := 1 - reduction/100
+	if factor < 0 {
+		factor = 0
+	}
+	r.strength *= factor
+	r.dexterity *= factor
+	r.intelligence *= factor
+	return r, nil
+}
+
+// verifyRequirements reports the items that can drop with requirements above the budget of the equipment.
+// Bases without explicit requirements are reported as well because the equations of their itemCostName are not evaluated.
+func (e *Equipment) verifyRequirements() error {
+	budget := e.budget()
+	if budget == (requirements{}) {
+		return nil
+	}
+	records := make(map[string]*dbr.Record)
+	record := func(path string) (*dbr.Record, error) {
+		if rec, ok := records[arz.CleanPath(path)]; ok {
+			return rec, nil
+		}
+		rec, err := e.db.Record(path)
+		if err != nil {
+			return nil, err
+		}
+		records[arz.CleanPath(path)] = rec
+		return rec, nil
+	}
+	for _, i := range e.allItems() {
+		for _, base := range i.bases() {
+			rec, err := record(base.Record)
+			if err != nil {
+				return fmt.Errorf("failed to read requirements of %s: %v", base.Record, err)
+			}
+			r, err := baseRequirements(rec)
+			if err != nil {
+				return fmt.Errorf("failed to read requirements of %s: %v", base.Record, err)
+			}
+			if r == (requirements{}) {
+				var unchecked string
+				if cost, _ := rec.Get("itemCostName"); cost != "" {
+					unchecked = ", the equations of " + cost + " are not checked"
+				}
+				if err := e.warnf("item %s has no explicit requirements%s", base.Record, unchecked); err != nil {
+					return err
+				}
+			}
+			for _, outcome := range i.affixOutcomes() {
+				var affixes []*dbr.Record
+				for _, affix := range outcome {
+					if affix == "" {
+						continue
+					}
+					affixRec, err := record(affix)
+					if err != nil {
+						return fmt.Errorf("failed to read requirements of %s: %v", affix, err)
+					}
+					affixes = append(affixes, affixRec)
+				}
+				worn, err := withAffixes(r, affixes)
+				if err != nil {
+					return fmt.Errorf("failed to read requirements of %s: %v", base.Record, err)
+				}
+				if exceeded := worn.exceeds(budget); exceeded != "" {
+					if err := e.warnf("item %s cannot be worn with %s: %s", base.Record, affixDescription(outcome), exceeded); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func affixDescription(affixes [2]string) string {
+	var names []string
+	for _, affix := range affixes {
+		if affix != "" {
+			names = append(names, affix)
+		}
+	}
+	if len(names) == 0 {
+		return "no affixes"
+	}
+	return strings.Join(names, " and ")
+}
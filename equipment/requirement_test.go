@@ -0,0 +1,141 @@
+package equipment
+
+import (
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/go-test/deep"
+)
+
+func TestAffixOutcomes(t *testing.T) {
+	half := 50.0
+	zero := 0.0
+	testData := []struct {
+		Name string
+		In   Item
+		Out  [][2]string
+	}{
+		{
+			Name: "NoAffixes",
+			In:   Item{},
+			Out:  [][2]string{{"", ""}},
+		},
+		{
+			Name: "AlwaysBoth",
+			In:   Item{PrefixRecord: "p.dbr", SuffixRecord: "s.dbr"},
+			Out:  [][2]string{{"p.dbr", "s.dbr"}},
+		},
+		{
+			Name: "SometimesPrefix",
+			In:   Item{PrefixRecord: "p.dbr", SuffixRecord: "s.dbr", PrefixChance: &half},
+			Out:  [][2]string{{"p.dbr", "s.dbr"}, {"", "s.dbr"}},
+		},
+		{
+			Name: "NeverBoth",
+			In:   Item{PrefixRecord: "p.dbr", SuffixRecord: "s.dbr", BothPrefixSuffix: &zero},
+			Out:  [][2]string{{"p.dbr", ""}, {"", "s.dbr"}},
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			if diff := deep.Equal(td.In.affixOutcomes(), td.Out); diff != nil {
+				t.Errorf("result differs from expected outcomes: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyRequirements(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	half := 50.0
+	sword := "records/item/equipmentweapon/sword/testsword.dbr"
+	costSword := "records/item/equipmentweapon/sword/testcostsword.dbr"
+	ease := "records/item/lootmagicalaffixes/suffix/default/testeasesuffix.dbr"
+	levelPrefix := "records/item/lootmagicalaffixes/prefix/default/testlevelprefix.dbr"
+	testData := []struct {
+		Name     string
+		InBudget Equipment
+		InItem   Item
+		InStrict bool
+		OK       bool
+	}{
+		{
+			Name:     "NoBudget",
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword},
+			InStrict: true,
+			OK:       true,
+		},
+		{
+			Name:     "WithinBudget",
+			InBudget: Equipment{Level: 40, Strength: 200, Dexterity: 150, Intelligence: 10},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword},
+			InStrict: true,
+			OK:       true,
+		},
+		{
+			Name:     "StrengthTooLow",
+			InBudget: Equipment{Strength: 180},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword},
+			InStrict: true,
+			OK:       false,
+		},
+		{
+			Name:     "StrengthTooLowWarning",
+			InBudget: Equipment{Strength: 180},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword},
+			OK:       true,
+		},
+		{
+			Name:     "AlwaysReduced",
+			InBudget: Equipment{Strength: 160},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword, SuffixRecord: ease},
+			InStrict: true,
+			OK:       true,
+		},
+		{
+			Name:     "SometimesReduced",
+			InBudget: Equipment{Strength: 160},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword, SuffixRecord: ease, SuffixChance: &half},
+			InStrict: true,
+			OK:       false,
+		},
+		{
+			Name:     "AffixLevelTooHigh",
+			InBudget: Equipment{Level: 45},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: sword, PrefixRecord: levelPrefix},
+			InStrict: true,
+			OK:       false,
+		},
+		{
+			Name:     "CostOnly",
+			InBudget: Equipment{Level: 45},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: costSword},
+			InStrict: true,
+			OK:       false,
+		},
+		{
+			Name:     "CostOnlyWarning",
+			InBudget: Equipment{Level: 45},
+			InItem:   Item{SlotIdentifier: WeaponRight, BaseRecord: costSword},
+			OK:       true,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := td.InBudget
+			e.Strict = td.InStrict
+			e.Items = []Item{td.InItem}
+			e.db = db
+			err := e.verifyRequirements()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package equipment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+)
+
+// Affix kinds.
+const (
+	Prefix = "prefix"
+	Suffix = "suffix"
+)
+
+// AffixQuery filters the affixes of the game database.
+// Empty filters match every affix.
+type AffixQuery struct {
+	// Stat matches affixes with a stat field that contains all words of Stat ignoring case.
+	// Common words of the game's descriptions are translated into the words of the field names,
+	// so "+% physical damage" matches offensivePhysicalModifier
+	// and "energy regeneration" matches characterManaRegen.
+	Stat string
+	// Class is the record class like WeaponMelee_Sword or the category like armor of the items
+	// the affix has to roll on.
+	Class string
+	// Rarity is the folder of the affix below the prefix or suffix folder like default or rare.
+	Rarity string
+	// Kind is Prefix or Suffix.
+	Kind string
+}
+
+// Affix is an affix of the game database that matched a query.
+type Affix struct {
+	Record string
+	Kind   string
+	Rarity string
+	// Stats are the fields of the affix that hold a bonus.
+	Stats []dbr.Field
+}
+
+// statWords translates words of the game's descriptions into the words of the field names.
+var statWords = map[string]string{
+	"%":            "modifier",
+	"+%":           "modifier",
+	"damage":       "offensive",
+	"energy":       "mana",
+	"health":       "life",
+	"regeneration": "regen",
+	"resistance":   "defensive",
+	"armor":        "defensiveprotection",
+	"attack":       "offensiveability",
+	"defense":      "defensiveability",
+}
+
+// nonStatFields are the fields of affix records that hold no bonus.
+var nonStatFields = map[string]bool{
+	"templateName":       true,
+	"ActorName":          true,
+	"Class":              true,
+	"FileDescription":    true,
+	"lootRandomizerName": true,
+	"levelRequirement":   true,
+}
+
+// statTerms splits a stat query into the lower case words field names have to contain.
+func statTerms(stat string) []string {
+	var terms []string
+	for _, word := range strings.Fields(strings.ToLower(stat)) {
+		if t, ok := statWords[word]; ok {
+			terms = append(terms, t)
+			continue
+		}
+		percent := strings.Contains(word, "%")
+		if word = strings.Trim(word, "+%"); word != "" {
+			terms = append(terms, word)
+		}
+		if percent {
+			terms = append(terms, "modifier")
+		}
+	}
+	return terms
+}
+
+// matchesStat reports whether a field name contains all terms.
+func matchesStat(field string, terms []string) bool {
+	field = strings.ToLower(field)
+	for _, t := range terms {
+		if !strings.Contains(field, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// affixStats returns the fields of an affix record that hold a bonus.
+func affixStats(rec *dbr.Record) []dbr.Field {
+	var stats []dbr.Field
+	for _, f := range rec.Fields() {
+		if nonStatFields[f.Key] || f.Value == "" {
+			continue
+		}
+		if v, err := strconv.ParseFloat(f.Value, 64); err == nil && v == 0 {
+			continue
+		}
+		stats = append(stats, f)
+	}
+	return stats
+}
+
+// affixKindAndRarity returns the kind and the rarity of an affix from its path.
+func affixKindAndRarity(path string) (string, string) {
+	parts := strings.Split(strings.TrimPrefix(path, affixFolder+`\`), `\`)
+	if len(parts) < 3 {
+		return "", ""
+	}
+	switch parts[0] {
+	case Prefix, Suffix:
+		return parts[0], parts[1]
+	}
+	return "", ""
+}
+
+// queryCategory returns the item category of the Class filter.
+func queryCategory(class string) (string, error) {
+	if category := categoryOfClass(class); category != "" {
+		return category, nil
+	}
+	for _, category := range []string{weaponCategory, shieldCategory, armorCategory, jewelleryCategory} {
+		if strings.EqualFold(class, category) {
+			return category, nil
+		}
+	}
+	return "", fmt.Errorf("%s is no item class or category", class)
+}
+
+// SearchAffixes returns all prefixes and suffixes of the game database that match the query.
+func SearchAffixes(db *arz.Archive, q AffixQuery) ([]Affix, error) {
+	switch q.Kind {
+	case "", Prefix, Suffix:
+	default:
+		return nil, fmt.Errorf("unexpected affix kind %s", q.Kind)
+	}
+	var categories map[string]map[string]bool
+	var category string
+	if q.Class != "" {
+		var err error
+		if category, err = queryCategory(q.Class); err != nil {
+			return nil, err
+		}
+		if categories, err = readAffixCategories(db); err != nil {
+			return nil, fmt.Errorf("failed to read affix tables: %v", err)
+		}
+	}
+	terms := statTerms(q.Stat)
+	var affixes []Affix
+	for _, path := range db.List(affixFolder) {
+		kind, rarity := affixKindAndRarity(path)
+		if kind == "" || (q.Kind != "" && kind != q.Kind) {
+			continue
+		}
+		if q.Rarity != "" && !strings.EqualFold(rarity, q.Rarity) {
+			continue
+		}
+		if class, _ := db.Class(path); class != affixClass {
+			continue
+		}
+		if allowed, ok := categories[path]; ok && category != "" && !allowed[category] {
+			continue
+		}
+		rec, err := db.Record(path)
+		if err != nil {
+			return nil, err
+		}
+		stats := affixStats(rec)
+		if len(terms) > 0 {
+			matched := false
+			for _, f := range stats {
+				if matchesStat(f.Key, terms) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		affixes = append(affixes, Affix{Record: path, Kind: kind, Rarity: rarity, Stats: stats})
+	}
+	return affixes, nil
+}
@@ -0,0 +1,143 @@
+package equipment
+
+import (
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/Deichindianer/tq-item-setup/dbr"
+	"github.com/go-test/deep"
+)
+
+func TestStatTerms(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  []string
+	}{
+		{Name: "PercentDamage", In: "+% Physical Damage", Out: []string{"modifier", "physical", "offensive"}},
+		{Name: "EnergyRegeneration", In: "energy regeneration", Out: []string{"mana", "regen"}},
+		{Name: "AttachedPercent", In: "life%", Out: []string{"life", "modifier"}},
+		{Name: "FieldName", In: "characterStrength", Out: []string{"characterstrength"}},
+		{Name: "Empty", In: "", Out: nil},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			if diff := deep.Equal(statTerms(td.In), td.Out); diff != nil {
+				t.Errorf("result differs from expected terms: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestSearchAffixes(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testData := []struct {
+		Name string
+		In   AffixQuery
+		Out  []string
+		OK   bool
+	}{
+		{
+			Name: "PercentDamage",
+			In:   AffixQuery{Stat: "+% physical damage"},
+			Out:  []string{`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`},
+			OK:   true,
+		},
+		{
+			Name: "PhysicalDamage",
+			In:   AffixQuery{Stat: "physical damage"},
+			Out: []string{
+				`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+				`records\item\lootmagicalaffixes\prefix\default\testweaponprefix.dbr`,
+			},
+			OK: true,
+		},
+		{
+			Name: "PhysicalDamageOnJewellery",
+			In:   AffixQuery{Stat: "physical damage", Class: "ArmorJewelry_Amulet"},
+			Out:  []string{`records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`},
+			OK:   true,
+		},
+		{
+			Name: "StrengthSuffixes",
+			In:   AffixQuery{Stat: "strength", Kind: Suffix},
+			Out:  nil,
+			OK:   true,
+		},
+		{
+			Name: "SuffixesOfCategory",
+			In:   AffixQuery{Kind: Suffix, Class: "weapon"},
+			Out:  []string{`records\item\lootmagicalaffixes\suffix\default\testeasesuffix.dbr`},
+			OK:   true,
+		},
+		{
+			Name: "Rarity",
+			In:   AffixQuery{Rarity: "rare"},
+			Out:  nil,
+			OK:   true,
+		},
+		{
+			Name: "InvalidClass",
+			In:   AffixQuery{Class: "Potion"},
+			OK:   false,
+		},
+		{
+			Name: "InvalidKind",
+			In:   AffixQuery{Kind: "infix"},
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			affixes, err := SearchAffixes(db, td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			var records []string
+			for _, a := range affixes {
+				records = append(records, a.Record)
+			}
+			if diff := deep.Equal(records, td.Out); diff != nil {
+				t.Errorf("result differs from expected affixes: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestSearchAffixStats(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	affixes, err := SearchAffixes(db, AffixQuery{Stat: "level", Kind: Prefix})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(affixes) != 0 {
+		t.Errorf("expected levelRequirement to be no stat but got %+v", affixes)
+	}
+	affixes, err = SearchAffixes(db, AffixQuery{Stat: "+% physical damage"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Affix{
+		{
+			Record: `records\item\lootmagicalaffixes\prefix\default\testprefix.dbr`,
+			Kind:   Prefix,
+			Rarity: "default",
+			Stats: []dbr.Field{
+				{Key: "characterStrength", Value: "10"},
+				{Key: "offensivePhysicalModifier", Value: "5.500000"},
+			},
+		},
+	}
+	if diff := deep.Equal(affixes, expected); diff != nil {
+		t.Errorf("result differs from expected affixes: %+v", diff)
+	}
+}
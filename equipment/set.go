@@ -0,0 +1,130 @@
+package equipment
+
+import (
+	"fmt"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+)
+
+// itemSet is a set of the game with the members the items of the equipment provide.
+type itemSet struct {
+	record  string
+	members []string
+	present map[string]bool
+}
+
+// missing returns the members of the set no item provides.
+func (s *itemSet) missing() []string {
+	var missing []string
+	for _, member := range s.members {
+		if !s.present[arz.CleanPath(member)] {
+			missing = append(missing, member)
+		}
+	}
+	return missing
+}
+
+// itemSets returns all sets the base records of the items belong to in the order they are referenced.
+func (e *Equipment) itemSets(items []Item) ([]*itemSet, error) {
+	var sets []*itemSet
+	byRecord := make(map[string]*itemSet)
+	for _, i := range items {
+		for _, base := range i.bases() {
+			rec, err := e.db.Record(base.Record)
+			if err != nil {
+				return nil, err
+			}
+			setRecord, ok := rec.Get("itemSetName")
+			if !ok || setRecord == "" {
+				continue
+			}
+			set, ok := byRecord[arz.CleanPath(setRecord)]
+			if !ok {
+				definition, err := e.db.Record(setRecord)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read set of %s: %v", base.Record, err)
+				}
+				set = &itemSet{
+					record:  setRecord,
+					members: definition.Values("setMembers"),
+					present: make(map[string]bool),
+				}
+				byRecord[arz.CleanPath(setRecord)] = set
+				sets = append(sets, set)
+			}
+			set.present[arz.CleanPath(base.Record)] = true
+		}
+	}
+	return sets, nil
+}
+
+// verifySets reports the pieces of sets no item of the equipment or of one of its difficulties provides.
+// If CompleteSets is set an item is added for every missing piece instead.
+func (e *Equipment) verifySets() error {
+	if err := e.verifyVariantSets(e, nil); err != nil {
+		return err
+	}
+	for k := range e.Difficulties {
+		if err := e.verifyVariantSets(e.difficulty(e.Difficulties[k]), &e.Difficulties[k].Items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyVariantSets reports the pieces of sets the items of the equipment or of a difficulty variant do not provide.
+// Items for the missing pieces are added to the variant and to added if CompleteSets is set.
+func (e *Equipment) verifyVariantSets(variant *Equipment, added *[]Item) error {
+	sets, err := e.itemSets(variant.Items)
+	if err != nil {
+		return err
+	}
+	for _, set := range sets {
+		for _, member := range set.missing() {
+			if !e.CompleteSets {
+				if err := e.warnf("set %s of %s is missing %s", set.record, variant.TablePath, member); err != nil {
+					return err
+				}
+				continue
+			}
+			item, err := e.setItem(member, variant.Items)
+			if err != nil {
+				if err := e.warnf("set %s of %s is missing %s: %v", set.record, variant.TablePath, member, err); err != nil {
+					return err
+				}
+				continue
+			}
+			variant.Items = append(variant.Items, item)
+			if added != nil {
+				*added = append(*added, item)
+			}
+		}
+	}
+	return nil
+}
+
+// setItem creates an item for a set piece in the first slot that accepts it and none of the items use.
+// Set pieces drop without any affixes.
+func (e *Equipment) setItem(record string, items []Item) (Item, error) {
+	class, ok := e.db.Class(record)
+	if !ok {
+		return Item{}, fmt.Errorf("%s is not in the database", record)
+	}
+	used := make(map[Slot]bool)
+	for _, i := range items {
+		used[i.SlotIdentifier] = true
+	}
+	for _, slot := range slotsOfClass(class) {
+		if used[slot] {
+			continue
+		}
+		zero := 0.0
+		return Item{
+			SlotIdentifier: slot,
+			BaseRecord:     record,
+			PrefixChance:   &zero,
+			SuffixChance:   &zero,
+		}, nil
+	}
+	return Item{}, fmt.Errorf("there is no free slot for %s items", class)
+}
@@ -0,0 +1,102 @@
+package equipment
+
+import (
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/go-test/deep"
+)
+
+func TestVerifySets(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	amulet := Item{SlotIdentifier: Amulet, BaseRecord: "records/item/equipmentamulet/testsetamulet.dbr"}
+	ring := Item{SlotIdentifier: RingRight, BaseRecord: "records/item/equipmentring/testsetring.dbr"}
+	noSet := Item{SlotIdentifier: Amulet, BaseRecord: "records/item/equipmentamulet/testamulet.dbr"}
+	zero := 0.0
+	testData := []struct {
+		Name         string
+		InItems      []Item
+		InStrict     bool
+		InComplete   bool
+		OutItemCount int
+		OK           bool
+	}{
+		{Name: "NoSet", InItems: []Item{noSet}, InStrict: true, OutItemCount: 1, OK: true},
+		{Name: "CompleteSet", InItems: []Item{amulet, ring}, InStrict: true, OutItemCount: 2, OK: true},
+		{Name: "MissingPieceWarning", InItems: []Item{amulet}, OutItemCount: 1, OK: true},
+		{Name: "MissingPieceStrict", InItems: []Item{amulet}, InStrict: true, OK: false},
+		{Name: "MissingPieceCompleted", InItems: []Item{ring}, InStrict: true, InComplete: true, OutItemCount: 2, OK: true},
+		{Name: "NoFreeSlotWarning", InItems: []Item{ring, noSet}, InComplete: true, OutItemCount: 2, OK: true},
+		{Name: "NoFreeSlotStrict", InItems: []Item{ring, noSet}, InStrict: true, InComplete: true, OK: false},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{
+				Strict:       td.InStrict,
+				CompleteSets: td.InComplete,
+				Items:        append([]Item(nil), td.InItems...),
+				db:           db,
+			}
+			err := e.verifySets()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if err != nil {
+				return
+			}
+			if len(e.Items) != td.OutItemCount {
+				t.Errorf("expected %d items got %d instead", td.OutItemCount, len(e.Items))
+			}
+		})
+	}
+	t.Run("DifficultyMissingPiece", func(t *testing.T) {
+		e := &Equipment{
+			Strict:       true,
+			Items:        []Item{noSet},
+			Difficulties: []Difficulty{{Name: "Epic", Items: []Item{amulet}}},
+			db:           db,
+		}
+		if err := e.verifySets(); err == nil {
+			t.Error("expected error but got nil")
+		}
+	})
+	t.Run("DifficultyCompleted", func(t *testing.T) {
+		e := &Equipment{
+			Strict:       true,
+			CompleteSets: true,
+			Items:        []Item{noSet},
+			Difficulties: []Difficulty{{Name: "Epic", Items: []Item{amulet}}},
+			db:           db,
+		}
+		if err := e.verifySets(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(e.Items) != 1 {
+			t.Errorf("expected 1 item got %d instead", len(e.Items))
+		}
+		if len(e.Difficulties[0].Items) != 2 || e.Difficulties[0].Items[1].SlotIdentifier != RingLeft {
+			t.Errorf("expected a ring to complete the set of the difficulty got %+v instead", e.Difficulties[0].Items)
+		}
+	})
+	t.Run("GeneratedItem", func(t *testing.T) {
+		e := &Equipment{CompleteSets: true, Items: []Item{ring}, db: db}
+		if err := e.verifySets(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := Item{
+			SlotIdentifier: Amulet,
+			BaseRecord:     `records\item\equipmentamulet\testsetamulet.dbr`,
+			PrefixChance:   &zero,
+			SuffixChance:   &zero,
+		}
+		if diff := deep.Equal(e.Items[1], expected); diff != nil {
+			t.Errorf("result differs from expected item: %+v", diff)
+		}
+	})
+}
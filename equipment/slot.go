@@ -0,0 +1,219 @@
+package equipment
+
+import (
+	"fmt"
+)
+
+// Slot is the slot where the equipment goes, lol
+type Slot int
+
+// Constants for all the item slots.
+// They can be converted to and from strings.
+// UnknownSlot is treated as an invalid slot and used in error cases.
+const (
+	// I know this could be iota but this is way more readable :)
+	UnknownSlot Slot = -1
+	Amulet      Slot = 0
+	Arm         Slot = 1
+	Head        Slot = 2
+	Leg         Slot = 3
+	RingLeft    Slot = 4
+	RingRight   Slot = 5
+	Torso       Slot = 6
+	WeaponLeft  Slot = 7
+	WeaponRight Slot = 8
+	Shield      Slot = 9
+	Bow         Slot = 10
+	Thrown      Slot = 11
+	Artifact    Slot = 12
+	Relic       Slot = 13
+	Charm       Slot = 14
+)
+
+// Item classes of the game's equipment records.
+const (
+	amuletClass    = "ArmorJewelry_Amulet"
+	ringClass      = "ArmorJewelry_Ring"
+	forearmClass   = "ArmorProtective_Forearm"
+	headClass      = "ArmorProtective_Head"
+	lowerBodyClass = "ArmorProtective_LowerBody"
+	upperBodyClass = "ArmorProtective_UpperBody"
+	swordClass     = "WeaponMelee_Sword"
+	axeClass       = "WeaponMelee_Axe"
+	maceClass      = "WeaponMelee_Mace"
+	spearClass     = "WeaponHunting_Spear"
+	bowClass       = "WeaponHunting_Bow"
+	thrownClass    = "WeaponHunting_RangedOneHand"
+	staffClass     = "WeaponMagical_Staff"
+	shieldClass    = "WeaponArmor_Shield"
+	artifactClass  = "ItemArtifact"
+	relicClass     = "ItemRelic"
+	charmClass     = "ItemCharm"
+)
+
+// slots lists the name of every valid slot, the item classes that go into it
+// and the name monster records use for it in their chanceToEquip<Field> and loot<Field>Item1 fields.
+// Slots monsters cannot equip have no field.
+var slots = []struct {
+	slot       Slot
+	name       string
+	classes    []string
+	equipField string
+}{
+	{Amulet, "Amulet", []string{amuletClass}, "Misc1"},
+	{Arm, "Arm", []string{forearmClass}, "Forearm"},
+	{Head, "Head", []string{headClass}, "Head"},
+	{Leg, "Leg", []string{lowerBodyClass}, "LowerBody"},
+	{RingLeft, "RingLeft", []string{ringClass}, "Finger1"},
+	{RingRight, "RingRight", []string{ringClass}, "Finger2"},
+	{Torso, "Torso", []string{upperBodyClass}, "Torso"},
+	{WeaponLeft, "WeaponLeft", []string{swordClass, axeClass, maceClass, shieldClass}, "LeftHand"},
+	{WeaponRight, "WeaponRight", []string{swordClass, axeClass, maceClass, spearClass, bowClass, thrownClass, staffClass}, "RightHand"},
+	{Shield, "Shield", []string{shieldClass}, "LeftHand"},
+	{Bow, "Bow", []string{bowClass}, "RightHand"},
+	{Thrown, "Thrown", []string{thrownClass}, "RightHand"},
+	{Artifact, "Artifact", []string{artifactClass}, ""},
+	{Relic, "Relic", []string{relicClass}, ""},
+	{Charm, "Charm", []string{charmClass}, ""},
+}
+
+// Slots returns all valid slots.
+func Slots() []Slot {
+	all := make([]Slot, 0, len(slots))
+	for _, s := range slots {
+		all = append(all, s.slot)
+	}
+	return all
+}
+
+// slotNames returns the names of all valid slots.
+func slotNames() []string {
+	names := make([]string, 0, len(slots))
+	for _, s := range slots {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+func (i Slot) String() string {
+	for _, s := range slots {
+		if s.slot == i {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// monsterEquipField returns the name monster records use for the slot
+// in their chanceToEquip<Field> and loot<Field>Item1 fields.
+func (i Slot) monsterEquipField() string {
+	for _, s := range slots {
+		if s.slot == i {
+			return s.equipField
+		}
+	}
+	return ""
+}
+
+// classes returns the item classes that go into the slot.
+func (i Slot) classes() []string {
+	for _, s := range slots {
+		if s.slot == i {
+			return s.classes
+		}
+	}
+	return nil
+}
+
+// accepts reports whether items of a class go into the slot.
+func (i Slot) accepts(class string) bool {
+	for _, c := range i.classes() {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySlots reports the base records whose class does not go into the slot of their item.
+func (e *Equipment) verifySlots() error {
+	for _, i := range e.allItems() {
+		for _, base := range i.bases() {
+			class, _ := e.db.Class(base.Record)
+			if i.SlotIdentifier.accepts(class) {
+				continue
+			}
+			if err := e.warnf("item %s is a %s which does not go into slot %s", base.Record, class, i.SlotIdentifier); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// slotsOfClass returns all slots items of a class go into.
+func slotsOfClass(class string) []Slot {
+	var matching []Slot
+	for _, s := range slots {
+		for _, c := range s.classes {
+			if c == class {
+				matching = append(matching, s.slot)
+			}
+		}
+	}
+	return matching
+}
+
+// SlotFromString converts a string representation of a slot into its constant value.
+func SlotFromString(s string) (Slot, error) {
+	for _, slot := range slots {
+		if slot.name == s {
+			return slot.slot, nil
+		}
+	}
+	return UnknownSlot, fmt.Errorf("unexpectected Slot %s", s)
+}
+
+// validate checks that the slot is one of the valid slots.
+func (i Slot) validate() error {
+	if i.String() == "" {
+		return fmt.Errorf("unexpectected Slot %d", int(i))
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler so slots are written by their name.
+func (i Slot) MarshalText() ([]byte, error) {
+	if err := i.validate(); err != nil {
+		return nil, err
+	}
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler so slots are read by their name.
+func (i *Slot) UnmarshalText(text []byte) error {
+	s, err := SlotFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*i = s
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler so slots are written by their name.
+func (i Slot) MarshalYAML() (interface{}, error) {
+	b, err := i.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler so slots are read by their name.
+func (i *Slot) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return i.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,326 @@
+package equipment
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arz"
+	"github.com/go-test/deep"
+	"github.com/go-yaml/yaml"
+)
+
+func TestSlotFromString(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   string
+		Out  Slot
+		OK   bool
+	}{
+		{
+			Name: "ValidSlot",
+			In:   "Amulet",
+			Out:  Amulet,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Arm",
+			Out:  Arm,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Head",
+			Out:  Head,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Leg",
+			Out:  Leg,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "RingLeft",
+			Out:  RingLeft,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "RingRight",
+			Out:  RingRight,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Torso",
+			Out:  Torso,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "WeaponLeft",
+			Out:  WeaponLeft,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "WeaponRight",
+			Out:  WeaponRight,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Shield",
+			Out:  Shield,
+			OK:   true,
+		},
+		{
+			Name: "ValidSlot",
+			In:   "Charm",
+			Out:  Charm,
+			OK:   true,
+		},
+		{
+			Name: "InvalidSlot",
+			In:   "FooBar",
+			Out:  UnknownSlot,
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			s, err := SlotFromString(td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if s != td.Out {
+				t.Errorf("expected %s got %s instead", td.Out, s)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   Slot
+		Out  string
+		OK   bool
+	}{
+		{
+			Name: "ValidSlotAmulet",
+			In:   Amulet,
+			Out:  "Amulet",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotArm",
+			In:   Arm,
+			Out:  "Arm",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotHead",
+			In:   Head,
+			Out:  "Head",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotLeg",
+			In:   Leg,
+			Out:  "Leg",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotRingLeft",
+			In:   RingLeft,
+			Out:  "RingLeft",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotRingRight",
+			In:   RingRight,
+			Out:  "RingRight",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotTorso",
+			In:   Torso,
+			Out:  "Torso",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotWeaponLeft",
+			In:   WeaponLeft,
+			Out:  "WeaponLeft",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotWeaponRight",
+			In:   WeaponRight,
+			Out:  "WeaponRight",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotShield",
+			In:   Shield,
+			Out:  "Shield",
+			OK:   true,
+		},
+		{
+			Name: "ValidSlotThrown",
+			In:   Thrown,
+			Out:  "Thrown",
+			OK:   true,
+		},
+		{
+			Name: "InvalidSlot",
+			In:   UnknownSlot,
+			Out:  "",
+			OK:   true,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			s := td.In.String()
+			if s != td.Out {
+				t.Errorf("expected %s got %s instead", td.Out, s)
+			}
+		})
+	}
+}
+
+func TestSlotsRoundTrip(t *testing.T) {
+	for _, slot := range Slots() {
+		s, err := SlotFromString(slot.String())
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if s != slot {
+			t.Errorf("expected %s got %s instead", slot, s)
+		}
+	}
+}
+
+func TestSlotUnmarshal(t *testing.T) {
+	testData := []struct {
+		Name   string
+		InYAML string
+		InJSON string
+		Out    []Slot
+		OK     bool
+	}{
+		{
+			Name:   "ValidSlots",
+			InYAML: "[Head, Shield, Relic]",
+			InJSON: `["Head", "Shield", "Relic"]`,
+			Out:    []Slot{Head, Shield, Relic},
+			OK:     true,
+		},
+		{
+			Name:   "InvalidSlot",
+			InYAML: "[Head, FooBar]",
+			InJSON: `["Head", "FooBar"]`,
+			OK:     false,
+		},
+		{
+			Name:   "NumericSlot",
+			InYAML: "[3]",
+			InJSON: "[3]",
+			OK:     false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			for format, unmarshal := range map[string]func() ([]Slot, error){
+				"YAML": func() ([]Slot, error) {
+					var s []Slot
+					err := yaml.Unmarshal([]byte(td.InYAML), &s)
+					return s, err
+				},
+				"JSON": func() ([]Slot, error) {
+					var s []Slot
+					err := json.Unmarshal([]byte(td.InJSON), &s)
+					return s, err
+				},
+			} {
+				s, err := unmarshal()
+				if err != nil && td.OK {
+					t.Errorf("unexpected %s error: %v", format, err)
+				}
+				if err == nil && !td.OK {
+					t.Errorf("expected %s error but got nil", format)
+				}
+				if err != nil {
+					continue
+				}
+				if diff := deep.Equal(s, td.Out); diff != nil {
+					t.Errorf("%s result differs from expected slots: %+v", format, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSlotMarshal(t *testing.T) {
+	in := []Slot{Amulet, Shield}
+	y, err := yaml.Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := deep.Equal(string(y), "- Amulet\n- Shield\n"); diff != nil {
+		t.Errorf("result differs from expected YAML: %+v", diff)
+	}
+	j, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := deep.Equal(string(j), `["Amulet","Shield"]`); diff != nil {
+		t.Errorf("result differs from expected JSON: %+v", diff)
+	}
+	if _, err := json.Marshal(UnknownSlot); err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestVerifySlots(t *testing.T) {
+	db, err := arz.Open("../testData/database.arz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sword := "records/item/equipmentweapon/sword/testsword.dbr"
+	testData := []struct {
+		Name     string
+		InItem   Item
+		InStrict bool
+		OK       bool
+	}{
+		{Name: "MatchingSlot", InItem: Item{SlotIdentifier: WeaponRight, BaseRecord: sword}, InStrict: true, OK: true},
+		{Name: "OffHandSlot", InItem: Item{SlotIdentifier: WeaponLeft, BaseRecord: sword}, InStrict: true, OK: true},
+		{Name: "ShieldSlot", InItem: Item{SlotIdentifier: Shield, BaseRecord: "records/item/equipmentshield/testshield.dbr"}, InStrict: true, OK: true},
+		{Name: "WrongSlot", InItem: Item{SlotIdentifier: Head, BaseRecord: sword}, InStrict: true, OK: false},
+		{Name: "WrongSlotWarning", InItem: Item{SlotIdentifier: Head, BaseRecord: sword}, OK: true},
+		{Name: "WrongSecondBase", InItem: Item{
+			SlotIdentifier: Amulet,
+			BaseRecord:     "records/item/equipmentamulet/testamulet.dbr",
+			Bases:          []WeightedRecord{{Record: sword}},
+		}, InStrict: true, OK: false},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			e := &Equipment{Strict: td.InStrict, Items: []Item{td.InItem}, db: db}
+			err := e.verifySlots()
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
@@ -2,12 +2,30 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
+	"github.com/Deichindianer/tq-item-setup/arz"
 	"github.com/Deichindianer/tq-item-setup/equipment"
 )
 
+// commands are the subcommands next to the default of flushing an equipment.
+var commands = map[string]func(args []string) error{
+	"search":  search,
+	"inspect": inspect,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
 	var pathFlag = flag.String("path", "str_lvl_45.yml", "set the path to the equipment yml, defaults to equipment.yml")
 	flag.Parse()
 	e, err := equipment.FromFile(*pathFlag)
@@ -18,3 +36,72 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// search prints the affixes of the game database that match the flags.
+// Arguments after the flags are used as stat if there is no stat flag.
+func search(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	var dbFlag = fs.String("db", "", "set the path to the database.arz of the game")
+	var statFlag = fs.String("stat", "", "only show affixes with this stat, e.g. \"+% physical damage\"")
+	var classFlag = fs.String("class", "", "only show affixes that roll on this item class or category, e.g. WeaponMelee_Sword or armor")
+	var rarityFlag = fs.String("rarity", "", "only show affixes of this rarity, e.g. default or rare")
+	var kindFlag = fs.String("kind", "", "only show prefixes or suffixes")
+	fs.Parse(args)
+	if *dbFlag == "" {
+		return fmt.Errorf("search needs the path to the database.arz of the game in -db")
+	}
+	stat := *statFlag
+	if stat == "" {
+		stat = strings.Join(fs.Args(), " ")
+	}
+	db, err := arz.Open(*dbFlag)
+	if err != nil {
+		return err
+	}
+	affixes, err := equipment.SearchAffixes(db, equipment.AffixQuery{
+		Stat:   stat,
+		Class:  *classFlag,
+		Rarity: *rarityFlag,
+		Kind:   strings.ToLower(*kindFlag),
+	})
+	if err != nil {
+		return err
+	}
+	for _, a := range affixes {
+		fmt.Printf("%s (%s, %s)\n", a.Record, a.Kind, a.Rarity)
+		for _, s := range a.Stats {
+			fmt.Printf("\t%s: %s\n", s.Key, s.Value)
+		}
+	}
+	return nil
+}
+
+// inspect prints a record and all records it references.
+// The record is read from the folders of the equipment in -path or -folder before the game database.
+func inspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	var pathFlag = fs.String("path", "", "set the path to the equipment yml to read its records and database")
+	var dbFlag = fs.String("db", "", "set the path to the database.arz of the game")
+	var folderFlag = fs.String("folder", "", "set the folder with loose records")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: inspect [flags] <record path>")
+	}
+	e := &equipment.Equipment{FolderPath: *folderFlag, DatabasePath: *dbFlag}
+	if *pathFlag != "" {
+		var err error
+		if e, err = equipment.FromFile(*pathFlag); err != nil {
+			return err
+		}
+	}
+	if *dbFlag != "" {
+		e.DatabasePath = *dbFlag
+		if err := e.OpenDatabase(); err != nil {
+			return err
+		}
+	}
+	if *folderFlag != "" {
+		e.FolderPath = *folderFlag
+	}
+	return e.Inspect(os.Stdout, fs.Arg(0))
+}
@@ -0,0 +1,104 @@
+// Package tags reads and writes Titan Quest text tag files.
+//
+// Tag files map the tags referenced by records to localised text,
+// one "tag=text" entry per line. The game ships them inside of Text_<language>.arc
+// archives encoded as UTF-16 with a byte order mark.
+package tags
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Deichindianer/tq-item-setup/arc"
+)
+
+// Tags maps tag names to their text.
+type Tags map[string]string
+
+// Parse reads a tag file.
+// UTF-16 little endian files with a byte order mark and UTF-8 files are supported.
+// Empty lines and lines starting with "//" are skipped.
+func Parse(b []byte) (Tags, error) {
+	text, err := decode(b)
+	if err != nil {
+		return nil, err
+	}
+	t := make(Tags)
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d is not a tag: %q", n+1, line)
+		}
+		t[line[:i]] = line[i+1:]
+	}
+	return t, nil
+}
+
+func decode(b []byte) (string, error) {
+	if bytes.HasPrefix(b, []byte{0xff, 0xfe}) {
+		b = b[2:]
+		if len(b)%2 != 0 {
+			return "", fmt.Errorf("UTF-16 text has an odd length")
+		}
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = uint16(b[2*i]) | uint16(b[2*i+1])<<8
+		}
+		return string(utf16.Decode(u)), nil
+	}
+	b = bytes.TrimPrefix(b, []byte{0xef, 0xbb, 0xbf})
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("text is neither UTF-16 nor UTF-8")
+	}
+	return string(b), nil
+}
+
+// FromArchive merges all tag files of a text archive.
+func FromArchive(a *arc.Archive) (Tags, error) {
+	t := make(Tags)
+	for _, name := range a.Files() {
+		if !strings.HasSuffix(name, ".txt") {
+			continue
+		}
+		b, err := a.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		file, err := Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+		}
+		for k, v := range file {
+			t[k] = v
+		}
+	}
+	return t, nil
+}
+
+// Bytes serialises the tags sorted by name the way the game stores them.
+func (t Tags) Bytes() []byte {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var text strings.Builder
+	for _, name := range names {
+		text.WriteString(name + "=" + t[name] + "\r\n")
+	}
+	u := utf16.Encode([]rune(text.String()))
+	b := make([]byte, 2, 2+2*len(u))
+	b[0], b[1] = 0xff, 0xfe
+	for _, c := range u {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
@@ -0,0 +1,98 @@
+package tags
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Deichindianer/tq-item-setup/arc"
+	"github.com/go-test/deep"
+)
+
+func TestParse(t *testing.T) {
+	testData := []struct {
+		Name string
+		In   []byte
+		Out  Tags
+		OK   bool
+	}{
+		{
+			Name: "UTF8",
+			In:   []byte("// comment\r\ntagA=Achilles' Helm\r\n\r\ntagB=a=b\n"),
+			Out:  Tags{"tagA": "Achilles' Helm", "tagB": "a=b"},
+			OK:   true,
+		},
+		{
+			Name: "UTF8ByteOrderMark",
+			In:   []byte("\xef\xbb\xbftagA=Ä\n"),
+			Out:  Tags{"tagA": "Ä"},
+			OK:   true,
+		},
+		{
+			Name: "UTF16",
+			In:   []byte{0xff, 0xfe, 't', 0, '=', 0, 0xc4, 0, '\n', 0},
+			Out:  Tags{"t": "Ä"},
+			OK:   true,
+		},
+		{
+			Name: "NoTag",
+			In:   []byte("tagA\n"),
+			OK:   false,
+		},
+		{
+			Name: "OddUTF16",
+			In:   []byte{0xff, 0xfe, 't'},
+			OK:   false,
+		},
+	}
+	for _, td := range testData {
+		t.Run(td.Name, func(t *testing.T) {
+			tags, err := Parse(td.In)
+			if err != nil && td.OK {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && !td.OK {
+				t.Error("expected error but got nil")
+			}
+			if diff := deep.Equal(tags, td.Out); diff != nil {
+				t.Errorf("result differs from expected tags: %+v", diff)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	in := Tags{"tagB": "Ä", "tagA": "A"}
+	b := in.Bytes()
+	if !bytes.HasPrefix(b, []byte{0xff, 0xfe, 't', 0, 'a', 0, 'g', 0, 'A', 0}) {
+		t.Errorf("expected sorted UTF-16 tags got %v instead", b)
+	}
+	out, err := Parse(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := deep.Equal(out, in); diff != nil {
+		t.Errorf("result differs from expected tags: %+v", diff)
+	}
+}
+
+func TestFromArchive(t *testing.T) {
+	w := arc.NewWriter()
+	w.Add("a.txt", Tags{"tagA": "A", "tagB": "B"}.Bytes())
+	w.Add("b.txt", []byte("tagB=Override\n"))
+	w.Add("readme.md", []byte("not a tag file"))
+	var b bytes.Buffer
+	if _, err := w.WriteTo(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a, err := arc.Parse(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := FromArchive(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := deep.Equal(out, Tags{"tagA": "A", "tagB": "Override"}); diff != nil {
+		t.Errorf("result differs from expected tags: %+v", diff)
+	}
+}